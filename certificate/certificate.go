@@ -0,0 +1,99 @@
+// Package certificate prints issued school certificates as A4 PDFs.
+package certificate
+
+import (
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Document is a certificate ready to print. Body is plain text; blank lines
+// separate paragraphs.
+type Document struct {
+	School 				string
+	Title 				string
+	SerialNumber 	string
+	Date 					string
+	Body 					string
+	Signatures 		[]string
+	Cancelled 		bool
+}
+
+// Page layout in millimetres and points.
+const (
+	margin = 20.0
+	border = 10.0
+	schoolSize = 18.0
+	titleSize = 15.0
+	bodySize = 12.0
+	lineHeight = 7.0
+)
+
+// PDF writes the document on one A4 page, or more when the body is long.
+func (d Document) PDF(w io.Writer) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("go", "B", gobold.TTF)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin + 25)
+	pageWidth, pageHeight := pdf.GetPageSize()
+	width := pageWidth - 2 * margin
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetDrawColor(60, 60, 60)
+		pdf.SetLineWidth(0.6)
+		pdf.Rect(border, border, pageWidth - 2 * border, pageHeight - 2 * border, "D")
+		if d.Cancelled {
+			pdf.SetFont("go", "B", 60)
+			pdf.SetTextColor(220, 200, 200)
+			pdf.TransformBegin()
+			pdf.TransformRotate(35, pageWidth / 2, pageHeight / 2)
+			pdf.Text(pageWidth / 2 - pdf.GetStringWidth("CANCELLED") / 2, pageHeight / 2, "CANCELLED")
+			pdf.TransformEnd()
+		}
+		pdf.SetTextColor(20, 20, 20)
+	})
+	pdf.AddPage()
+
+	if d.School != "" {
+		pdf.SetFont("go", "B", schoolSize)
+		pdf.MultiCell(width, 9, d.School, "", "C", false)
+		pdf.Ln(4)
+	}
+	pdf.SetFont("go", "B", titleSize)
+	titleWidth := pdf.GetStringWidth(d.Title)
+	pdf.CellFormat(width, 8, d.Title, "", 1, "C", false, 0, "")
+	y := pdf.GetY()
+	pdf.SetLineWidth(0.3)
+	pdf.Line(margin + (width - titleWidth) / 2, y, margin + (width + titleWidth) / 2, y)
+	pdf.Ln(8)
+
+	pdf.SetFont("go", "", bodySize - 1)
+	pdf.CellFormat(width / 2, 6, "No. " + d.SerialNumber, "", 0, "L", false, 0, "")
+	pdf.CellFormat(width / 2, 6, "Date: " + d.Date, "", 1, "R", false, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont("go", "", bodySize)
+	pdf.MultiCell(width, lineHeight, d.Body, "", "J", false)
+
+	signatures := d.Signatures
+	if len(signatures) == 0 {
+		signatures = []string{"Clerk", "Principal"}
+	}
+	signatureY := pageHeight - margin - 15
+	if pdf.GetY() + 20 > signatureY {
+		pdf.AddPage()
+	}
+	pdf.SetAutoPageBreak(false, 0)
+	slot := width / float64(len(signatures))
+	pdf.SetFont("go", "B", bodySize - 1)
+	for i, signature := range signatures {
+		x := margin + float64(i) * slot
+		pdf.Line(x + slot * 0.15, signatureY, x + slot * 0.85, signatureY)
+		pdf.SetXY(x, signatureY + 1)
+		pdf.CellFormat(slot, 6, signature, "", 0, "C", false, 0, "")
+	}
+	return pdf.Output(w)
+}
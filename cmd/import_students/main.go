@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"swapnil-ex/models"
+	"swapnil-ex/models/db"
+	"swapnil-ex/sheet"
+)
+
+// Imports students from a CSV or XLSX file into swapnil.db of the current
+// directory. Without -commit only the validation report is printed.
+func main() {
+	defer db.Close()
+
+	mapping := flag.String("mapping", "", `column mapping as JSON, e.g. {"Surname": "last_name"}`)
+	batchStandardId := flag.Uint("batch_standard_id", 0, "enroll every student into this batch standard")
+	hostelId := flag.Uint("hostel_id", 0, "assign every student to this hostel")
+	hostelRoomId := flag.Uint("hostel_room_id", 0, "assign every student to this hostel room")
+	feeIncluded := flag.Bool("fee_included", false, "hostel fee is included in the class fee")
+	allOrNothing := flag.Bool("all_or_nothing", false, "save nothing when any row fails")
+	commit := flag.Bool("commit", false, "save the valid students")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import_students [flags] students.csv|students.xlsx")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	options := models.StudentImportOptions{Mapping: map[string]string{}, BatchStandardId: *batchStandardId,
+		HostelId: *hostelId, HostelRoomId: *hostelRoomId, FeeIncluded: *feeIncluded, AllOrNothing: *allOrNothing,
+		DryRun: !*commit}
+	if *mapping != "" {
+		if err := json.Unmarshal([]byte(*mapping), &options.Mapping); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid mapping:", err)
+			os.Exit(2)
+		}
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	rows, err := sheet.Read(file.Name(), file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	report, err := models.ImportStudents(rows, options)
+	for _, row := range report.Rows {
+		if len(row.Errors) > 0 {
+			fmt.Printf("row %d %s: %v\n", row.Row, row.Name, row.Errors)
+		}
+	}
+	fmt.Printf("valid: %d, invalid: %d, imported: %d\n", report.Valid, report.Invalid, report.Imported)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if report.Invalid > 0 {
+		os.Exit(1)
+	}
+}
@@ -30,15 +30,37 @@ func main() {
 	e.GET("/students", handlers.GetStudents, handlers.IsLoggedIn)
 	e.GET("/students/:id", handlers.GetStudent, handlers.IsLoggedIn)
 	e.POST("/students", handlers.CreateStudent, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/export", handlers.ExportStudents, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/import", handlers.ImportStudents, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/status", handlers.ChangeStudentsStatus, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/tags", handlers.TagStudents, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/untag", handlers.UntagStudents, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 	e.PUT("/students/:id", handlers.UpdateStudent, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 	e.DELETE("/students/:id", handlers.DeleteStudent, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 	
 	e.GET("/students/:id/hostel", handlers.GetStudentHostel, handlers.IsLoggedIn)
 	e.POST("/students/:id/assign_hostel", handlers.AssignStudentHostel, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 	e.PUT("/students/:id/change_hostel", handlers.ChangeStudentHostel, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/:id/hostel_transfers", handlers.GetStudentHostelTransfers, handlers.IsLoggedIn)
+	e.PUT("/students/:id/leave_hostel", handlers.LeaveStudentHostel, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/:id/adhar_card", handlers.RevealStudentAadhaar, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/students/:id/adhar_card/access_logs", handlers.GetStudentAadhaarAccessLogs, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.PUT("/students/:id/status", handlers.ChangeStudentStatus, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/:id/status_changes", handlers.GetStudentStatusChanges, handlers.IsLoggedIn)
+	e.GET("/students/:id/duplicates", handlers.GetStudentDuplicates, handlers.IsLoggedIn)
+	e.POST("/students/:id/merge", handlers.MergeStudent, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/students/:id/merges", handlers.GetStudentMerges, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/students/:id/timeline", handlers.GetStudentTimeline, handlers.IsLoggedIn)
+	e.GET("/students/:id/id_card", handlers.GetStudentIdCard, handlers.IsLoggedIn)
+	e.GET("/students/:id/tags", handlers.GetStudentTags, handlers.IsLoggedIn)
+	e.GET("/students/:student_id/notes", handlers.GetStudentNotes, handlers.IsLoggedIn)
+	e.POST("/students/:student_id/notes", handlers.CreateStudentNote, handlers.IsLoggedIn)
+	e.DELETE("/students/:student_id/notes/:id", handlers.DeleteStudentNote, handlers.IsLoggedIn, handlers.OnlyAdmin)
 	
 	e.GET("/students/:student_id/batch_standards", handlers.GetBatchStandardStudents, handlers.IsLoggedIn)
 	e.POST("/students/:student_id/batch_standards", handlers.CreateStudentBatchStandard, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/:student_id/batch_standards/transfer", handlers.TransferStudentBatchStandard, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/:student_id/batch_standard_transfers", handlers.GetStudentBatchStandardTransfers, handlers.IsLoggedIn)
 
 	e.GET("/students/:student_id/transactions", handlers.GetStudentTransactions, handlers.IsLoggedIn)
 	e.GET("/students/:student_id/transactions/:id", handlers.GetStudentTransaction, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
@@ -50,6 +72,30 @@ func main() {
 	e.POST("/students/:student_id/student_accounts/deposit", handlers.DepositStudentAccountAmount, handlers.IsLoggedIn)
 	e.POST("/students/:student_id/student_accounts/withdraw", handlers.WithdrawStudentAccountAmount, handlers.IsLoggedIn)
 
+	e.GET("/students/:student_id/deposits", handlers.GetStudentDeposits, handlers.IsLoggedIn)
+	e.POST("/students/:student_id/deposits", handlers.CreateStudentDeposit, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/:student_id/deposits/:id/deductions", handlers.CreateStudentDepositDeduction, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/:student_id/deposits/:id/settle", handlers.SettleStudentDeposit, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/:student_id/documents", handlers.GetStudentDocuments, handlers.IsLoggedIn)
+	e.POST("/students/:student_id/documents", handlers.UploadStudentDocument, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/students/:student_id/documents/:id", handlers.DownloadStudentDocument, handlers.IsLoggedIn)
+	e.DELETE("/students/:student_id/documents/:id", handlers.DeleteStudentDocument, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
+	e.GET("/students/:student_id/guardians", handlers.GetStudentGuardians, handlers.IsLoggedIn)
+	e.POST("/students/:student_id/guardians", handlers.AddStudentGuardian, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.PUT("/students/:student_id/guardians/:id/primary", handlers.SetStudentPrimaryGuardian, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.DELETE("/students/:student_id/guardians/:id", handlers.RemoveStudentGuardian, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.PUT("/guardians/:id", handlers.UpdateGuardian, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/guardians/:id/students", handlers.GetGuardianStudents, handlers.IsLoggedIn)
+
+	e.GET("/students/:student_id/write_offs", handlers.GetStudentWriteOffs, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/students/:student_id/write_offs", handlers.CreateStudentWriteOff, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.POST("/students/:student_id/write_offs/:id/reverse", handlers.ReverseStudentWriteOff, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/write_offs/report", handlers.GetWriteOffsReport, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
+	e.GET("/students/:student_id/pos_sales", handlers.GetStudentPosSales, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/students/:student_id/pos_sales", handlers.CreateStudentPosSale, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+
 	e.GET("/standards", handlers.GetStandards, handlers.IsLoggedIn)
 	e.GET("/standards/:id", handlers.GetStandard, handlers.IsLoggedIn)
 	e.POST("/standards", handlers.CreateStandard, handlers.IsLoggedIn, handlers.OnlyAdmin)
@@ -67,6 +113,13 @@ func main() {
 	e.POST("/batchs/:batch_id/batch-standards", handlers.CreateBatchStandard, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 	e.GET("/batchs/:batch_id/batch-standards/:id", handlers.GetBatchStandard, handlers.IsLoggedIn)
 	e.PUT("/batchs/:batch_id/batch-standards/:id", handlers.UpdateBatchStandard, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/batchs/:batch_id/batch-standards/:id/missing_documents", handlers.GetMissingDocumentsReport, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/batchs/:batch_id/batch-standards/:id/id_cards", handlers.GetBatchStandardIdCards, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/batchs/:batch_id/batch-standards/:id/roll_numbers", handlers.RenumberBatchStandardRollNumbers, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/batchs/:batch_id/batch-standards/:id/fee_revisions", handlers.GetFeeRevisions, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/batchs/:batch_id/batch-standards/:id/fee_revisions", handlers.CreateFeeRevision, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/batchs/:batch_id/batch-standards/:id/fee_revisions/:revision_id/preview", handlers.PreviewFeeRevision, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/batchs/:batch_id/batch-standards/:id/fee_revisions/:revision_id/apply", handlers.ApplyFeeRevision, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 
 	e.GET("/hostels", handlers.GetHostels, handlers.IsLoggedIn)
 	e.GET("/hostels/:id", handlers.GetHostel, handlers.IsLoggedIn)
@@ -74,6 +127,11 @@ func main() {
 	e.PUT("/hostels/:id", handlers.UpdateHostel, handlers.IsLoggedIn, handlers.OnlyAdmin)
 	e.DELETE("/hostels/:id", handlers.DeleteHostel, handlers.IsLoggedIn, handlers.OnlyAdmin)
 
+	e.GET("/hostels/billing/preview", handlers.GetHostelBillingPreview, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/hostels/billing", handlers.RunHostelBilling, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
+	e.GET("/hostels/deposits", handlers.GetHostelDepositsReport, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
 	e.GET("/hostels/:hostel_id/hostel_rooms", handlers.GetHostelRooms, handlers.IsLoggedIn)
 	e.POST("/hostels/:hostel_id/hostel_rooms", handlers.CreateHostelRoom, handlers.IsLoggedIn, handlers.OnlyAdmin)
 	e.GET("/hostels/:hostel_id/hostel_rooms/:id", handlers.GetHostelRoom, handlers.IsLoggedIn)
@@ -81,8 +139,63 @@ func main() {
 	e.GET("/hostels/:hostel_id/hostel_rooms/:id/students", handlers.GetHostelRoomStudents, handlers.IsLoggedIn)
 
 	e.GET("/accounts/transactions", handlers.GetTransactions, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/accounts/student_accounts", handlers.GetAllStudentAccounts, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 	e.GET("/accounts/students/:student_id/transactions/:id", handlers.GetStudentTransaction, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
 
+	e.GET("/pos/items", handlers.GetPosItems, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.GET("/pos/items/low_stock", handlers.GetLowStockPosItems, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/pos/items", handlers.CreatePosItem, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.PUT("/pos/items/:id", handlers.UpdatePosItem, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/pos/items/:id/restock", handlers.RestockPosItem, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.GET("/pos/sales/summary", handlers.GetPosSalesSummary, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+
+	e.GET("/id_cards/verify", handlers.VerifyIdCard, handlers.IsLoggedIn)
+
+	e.GET("/promotions", handlers.GetPromotions, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/promotions/:id", handlers.GetPromotion, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/promotions/preview", handlers.PreviewPromotion, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/promotions", handlers.CreatePromotion, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
+	e.GET("/fiscal_years", handlers.GetFiscalYears, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.POST("/fiscal_years", handlers.CreateFiscalYear, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.POST("/fiscal_years/:id/close", handlers.CloseFiscalYear, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/fiscal_years/:id/report", handlers.GetFiscalYearReport, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
+	e.GET("/custom_fields", handlers.GetCustomFields, handlers.IsLoggedIn)
+	e.POST("/custom_fields", handlers.CreateCustomField, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.PUT("/custom_fields/:id", handlers.UpdateCustomField, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.DELETE("/custom_fields/:id", handlers.DeleteCustomField, handlers.IsLoggedIn, handlers.OnlyAdmin)
+
+	e.GET("/tags", handlers.GetTags, handlers.IsLoggedIn)
+	e.PUT("/tags/:id", handlers.UpdateTag, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.DELETE("/tags/:id", handlers.DeleteTag, handlers.IsLoggedIn, handlers.OnlyAdmin)
+
+	e.GET("/segments", handlers.GetStudentSegments, handlers.IsLoggedIn)
+	e.POST("/segments", handlers.CreateStudentSegment, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.PUT("/segments/:id", handlers.UpdateStudentSegment, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.DELETE("/segments/:id", handlers.DeleteStudentSegment, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+	e.GET("/segments/:id/students", handlers.GetStudentSegmentStudents, handlers.IsLoggedIn)
+	e.GET("/segments/:id/contacts", handlers.GetStudentSegmentContacts, handlers.IsLoggedIn)
+	e.GET("/segments/:id/report", handlers.GetStudentSegmentReport, handlers.IsLoggedIn, handlers.OnlyAdminAccountant)
+
+	e.GET("/certificate_templates", handlers.GetCertificateTemplates, handlers.IsLoggedIn)
+	e.POST("/certificate_templates", handlers.CreateCertificateTemplate, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.PUT("/certificate_templates/:id", handlers.UpdateCertificateTemplate, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.DELETE("/certificate_templates/:id", handlers.DeleteCertificateTemplate, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/certificates", handlers.GetCertificates, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.GET("/certificates/:id/pdf", handlers.GetCertificatePDF, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/certificates/:id/cancel", handlers.CancelCertificate, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/students/:id/certificates", handlers.GetStudentCertificates, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/students/:id/certificates/preview", handlers.PreviewStudentCertificate, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+	e.POST("/students/:id/certificates", handlers.IssueStudentCertificate, handlers.IsLoggedIn, handlers.OnlyAdminClerk)
+
+	e.GET("/trash", handlers.GetTrashSummaries, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/trash/logs", handlers.GetTrashLogs, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.GET("/trash/:entity", handlers.GetTrash, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.POST("/trash/:entity/purge", handlers.PurgeExpiredTrash, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.POST("/trash/:entity/:id/restore", handlers.RestoreTrash, handlers.IsLoggedIn, handlers.OnlyAdmin)
+	e.DELETE("/trash/:entity/:id", handlers.PurgeTrash, handlers.IsLoggedIn, handlers.OnlyAdmin)
+
 	e.GET("/users", handlers.GetUsers, handlers.IsLoggedIn, handlers.OnlyAdmin)
 	e.POST("/users", handlers.Register, handlers.IsLoggedIn, handlers.OnlyAdmin)
 
@@ -2,4 +2,10 @@ package constants
 
 const (
 	SESSION_EXPIRY = 24
+	YEAR_START_MONTH = 4
+	HOSTEL_TERM_MONTHS = 6
+	DOCUMENT_MAX_SIZE = 5 << 20
+	ID_CARD_KEY_FILE = "id_card.key"
+	TRASH_RETENTION_DAYS = 30
+	ID_CARD_VALIDITY_DAYS = 365
 )
@@ -13,13 +13,21 @@ import (
 func GetBatchs(c echo.Context) error {
 	// Get all users
 	b := &models.Batch{}
-	batchs, err := b.All()
+	lq, err := GetListQuery(c, models.BatchListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	batchs, err := b.All(lq)
 	if err != nil {
 		fmt.Println("s.ALL(GetBatchs)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-
-	return c.JSON(http.StatusOK, batchs)
+	count, err := b.Count(lq)
+	if err != nil {
+		fmt.Println("s.ALL(GetBatchs)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, batchs, count)
 }
 
 func GetBatch(c echo.Context) error {
@@ -124,7 +124,16 @@ func UpdateBatchStandard(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
+	previousFee := bs.Fee
 	bs.Assign(batchStandardData)
+	if err := bs.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	// the fee only changes through a fee revision, which keeps its history
+	if bs.Fee != previousFee {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": map[string][]string{"Fee": {swapErr.ErrFeeRevisionRequired.Error()}}})
+	}
 	if err := bs.Update(); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
 	}
@@ -170,12 +179,21 @@ func GetBatchStandards(c echo.Context) error {
 	}
 
 	bs := &models.BatchStandard{}
-	batchStandards, err := bs.All(b.ID)
+	lq, err := GetListQuery(c, models.BatchStandardListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	batchStandards, err := bs.All(lq, b.ID)
 	if err != nil {
 		fmt.Println("s.ALL(GetBatchs)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, batchStandards)
+	count, err := bs.Count(lq, b.ID)
+	if err != nil {
+		fmt.Println("s.ALL(GetBatchs)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, batchStandards, count)
 }
 
 func GetBatchUnassignedStandards(c echo.Context) error {
@@ -209,3 +227,33 @@ func GetBatchUnassignedStandards(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, standards)
 }
+
+func RenumberBatchStandardRollNumbers(c echo.Context) error {
+	bs, err := GetBatchStandardParam(c)
+	if err != nil {
+		fmt.Println("GetBatchStandardParam(RenumberBatchStandardRollNumbers)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	rollNumberData := make(map[string]interface{})
+	if err := c.Bind(&rollNumberData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	bs.Assign(rollNumberData)
+	if err := bs.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := bs.Update(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	batchStandardStudents, err := bs.RenumberRollNumbers()
+	if err != nil {
+		fmt.Println("bs.RenumberRollNumbers(RenumberBatchStandardRollNumbers)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Roll numbers assigned", "batch_standard": bs, "students": batchStandardStudents})
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetCertificateTemplates(c echo.Context) error {
+	ct := &models.CertificateTemplate{}
+	certificateTemplates, err := ct.All(c.QueryParam("kind"))
+	if err != nil {
+		fmt.Println("ct.All(GetCertificateTemplates)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, certificateTemplates)
+}
+
+func CreateCertificateTemplate(c echo.Context) error {
+	certificateTemplateData := make(map[string]interface{})
+	if err := c.Bind(&certificateTemplateData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	certificateTemplate := models.NewCertificateTemplate(certificateTemplateData)
+	if err := certificateTemplate.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := certificateTemplate.Create(); err != nil {
+		fmt.Println("ct.Create(CreateCertificateTemplate)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "certificate template created", "certificate_template": certificateTemplate})
+}
+
+func UpdateCertificateTemplate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	certificateTemplateData := make(map[string]interface{})
+	if err := c.Bind(&certificateTemplateData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	certificateTemplate := &models.CertificateTemplate{ID: uint(id)}
+	if err := certificateTemplate.Find(); err != nil {
+		fmt.Println("ct.Find(UpdateCertificateTemplate)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Certificate template not found"})
+	}
+	certificateTemplate.Assign(certificateTemplateData)
+	if err := certificateTemplate.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := certificateTemplate.Update(); err != nil {
+		fmt.Println("ct.Update(UpdateCertificateTemplate)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "certificate template updated", "certificate_template": certificateTemplate})
+}
+
+func DeleteCertificateTemplate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	certificateTemplate := &models.CertificateTemplate{ID: uint(id)}
+	if err := certificateTemplate.Find(); err != nil {
+		fmt.Println("ct.Find(DeleteCertificateTemplate)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Certificate template not found"})
+	}
+	if err := certificateTemplate.Delete(); err != nil {
+		fmt.Println("ct.Delete(DeleteCertificateTemplate)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "certificate template deleted"})
+}
+
+// PreviewStudentCertificate renders a certificate for the student without
+// issuing it, so the text can be checked first.
+func PreviewStudentCertificate(c echo.Context) error {
+	return issueStudentCertificate(c, true)
+}
+
+// IssueStudentCertificate enters a certificate in the register under the
+// next serial number. Print it with GetCertificatePDF.
+func IssueStudentCertificate(c echo.Context) error {
+	return issueStudentCertificate(c, false)
+}
+
+func issueStudentCertificate(c echo.Context, preview bool) error {
+	cc := c.(CustomContext)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	certificateData := make(map[string]interface{})
+	if err := c.Bind(&certificateData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	templateId, _ := certificateData["certificate_template_id"].(float64)
+	fields := map[string]string{}
+	if fieldsData, ok := certificateData["fields"].(map[string]interface{}); ok {
+		for name, value := range fieldsData {
+			fields[name] = strings.TrimSpace(fmt.Sprint(value))
+		}
+	}
+
+	certificateTemplate := &models.CertificateTemplate{ID: uint(templateId)}
+	if err := certificateTemplate.Find(); err != nil {
+		fmt.Println("ct.Find(IssueStudentCertificate)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(id)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	var certificate *models.Certificate
+	if preview {
+		certificate, err = student.RenderCertificate(certificateTemplate, fields)
+	} else {
+		certificate, err = student.IssueCertificate(certificateTemplate, fields, cc.session.UserID)
+	}
+	if errors.Is(err, swapErr.ErrCertificateFields) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if err == swapErr.ErrBalanceNotCleared || err == swapErr.ErrCertificateIssued || err == swapErr.ErrStudentLeft ||
+		err == swapErr.ErrNoClassAssigned || err == swapErr.ErrNoFeesPaid {
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("s.IssueCertificate(IssueStudentCertificate)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	if preview {
+		return c.JSON(http.StatusOK, certificate)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Certificate issued", "certificate": certificate})
+}
+
+func GetStudentCertificates(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	lq, err := GetListQuery(c, models.CertificateListFields, "-id", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	lq.Filters = append(lq.Filters, models.ListFilter{Field: "student_id", Column: "student_id", Op: "eq", Value: strconv.Itoa(id)})
+	return listCertificates(c, lq)
+}
+
+// GetCertificates is the register of issued certificates, newest first.
+func GetCertificates(c echo.Context) error {
+	lq, err := GetListQuery(c, models.CertificateListFields, "-id", 20)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return listCertificates(c, lq)
+}
+
+func listCertificates(c echo.Context, lq *models.ListQuery) error {
+	certificate := &models.Certificate{}
+	certificates, err := certificate.All(lq)
+	if err != nil {
+		fmt.Println("c.All(GetCertificates)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	total, err := certificate.Count(lq)
+	if err != nil {
+		fmt.Println("c.Count(GetCertificates)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, certificates, total)
+}
+
+// GetCertificatePDF prints an issued certificate. Cancelled ones are
+// printed marked as such.
+func GetCertificatePDF(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	certificate := &models.Certificate{ID: uint(id)}
+	if err := certificate.Find(); err != nil {
+		fmt.Println("c.Find(GetCertificatePDF)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Certificate not found"})
+	}
+
+	var buf bytes.Buffer
+	if err := certificate.Document().PDF(&buf); err != nil {
+		fmt.Println("certificate.PDF(GetCertificatePDF)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	fileName := strings.ReplaceAll(certificate.SerialNumber, "/", "-")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%s.pdf", fileName))
+	return c.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func CancelCertificate(c echo.Context) error {
+	cc := c.(CustomContext)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	cancelData := make(map[string]interface{})
+	if err := c.Bind(&cancelData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	reason, _ := cancelData["reason"].(string)
+	if strings.TrimSpace(reason) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	certificate := &models.Certificate{ID: uint(id)}
+	if err := certificate.Find(); err != nil {
+		fmt.Println("c.Find(CancelCertificate)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Certificate not found"})
+	}
+	err = certificate.Cancel(reason, cc.session.UserID)
+	if err == swapErr.ErrCertificateCancelled {
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("c.Cancel(CancelCertificate)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Certificate cancelled", "certificate": certificate})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetCustomFields(c echo.Context) error {
+	cf := &models.CustomField{}
+	customFields, err := cf.All()
+	if err != nil {
+		fmt.Println("cf.All(GetCustomFields)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, customFields)
+}
+
+func CreateCustomField(c echo.Context) error {
+	customFieldData := make(map[string]interface{})
+	if err := c.Bind(&customFieldData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	customField := models.NewCustomField(customFieldData)
+	if err := customField.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := customField.Create(); err != nil {
+		fmt.Println("cf.Create(CreateCustomField)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "custom field created", "custom_field": customField})
+}
+
+func UpdateCustomField(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	customFieldData := make(map[string]interface{})
+	if err := c.Bind(&customFieldData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	customField := &models.CustomField{ID: uint(id)}
+	if err := customField.Find(); err != nil {
+		fmt.Println("cf.Find(UpdateCustomField)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Custom field not found"})
+	}
+	customField.Assign(customFieldData)
+	if err := customField.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := customField.Update(); err != nil {
+		fmt.Println("cf.Update(UpdateCustomField)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "custom field updated", "custom_field": customField})
+}
+
+// DeleteCustomField removes the field along with every student's value.
+func DeleteCustomField(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	customField := &models.CustomField{ID: uint(id)}
+	if err := customField.Find(); err != nil {
+		fmt.Println("cf.Find(DeleteCustomField)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Custom field not found"})
+	}
+	if err := customField.Delete(); err != nil {
+		fmt.Println("cf.Delete(DeleteCustomField)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "custom field deleted"})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/validator.v2"
+)
+
+func GetStudentDeposits(c echo.Context) error {
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(newStudentId)}
+	err = student.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	d := &models.Deposit{}
+	deposits, err := d.All(student.ID)
+	if err != nil {
+		fmt.Println("d.All(GetStudentDeposits)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, deposits)
+}
+
+func CreateStudentDeposit(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(newStudentId)}
+	err = student.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	depositData := make(map[string]interface{})
+	if err := c.Bind(&depositData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	deposit := models.NewDeposit(depositData, student)
+	deposit.UserID = cc.session.UserID
+	if err := deposit.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+
+	err = deposit.Create()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Deposit created", "deposit": deposit})
+}
+
+func CreateStudentDepositDeduction(c echo.Context) error {
+	cc := c.(CustomContext)
+	deposit, err := GetStudentDeposit(c)
+	if err != nil {
+		fmt.Println("GetStudentDeposit(CreateStudentDepositDeduction)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	deductionData := make(map[string]interface{})
+	if err := c.Bind(&deductionData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	amount, _ := deductionData["amount"].(float64)
+	reason, _ := deductionData["reason"].(string)
+
+	deduction, err := deposit.Deduct(amount, reason, cc.session.UserID)
+	if err == swapErr.ErrDepositSettled || err == swapErr.ErrDeductionExceedsDeposit {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	}
+	if _, ok := err.(validator.ErrorMap); ok {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err != nil {
+		fmt.Println("d.Deduct(CreateStudentDepositDeduction)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Deposit deducted", "deposit": deposit, "deduction": deduction})
+}
+
+func SettleStudentDeposit(c echo.Context) error {
+	cc := c.(CustomContext)
+	deposit, err := GetStudentDeposit(c)
+	if err != nil {
+		fmt.Println("GetStudentDeposit(SettleStudentDeposit)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	err = deposit.Settle(cc.session.UserID)
+	if err == swapErr.ErrDepositSettled {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("d.Settle(SettleStudentDeposit)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Deposit settled", "deposit": deposit})
+}
+
+func GetHostelDepositsReport(c echo.Context) error {
+	summaries, err := models.HeldByHostel()
+	if err != nil {
+		fmt.Println("models.HeldByHostel(GetHostelDepositsReport)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	total := 0.0
+	for _, summary := range summaries {
+		total = total + summary.Held
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"hostels": summaries, "total": total})
+}
+
+func GetStudentDeposit(c echo.Context) (*models.Deposit, error) {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	deposit := &models.Deposit{ID: uint(id)}
+	if err := deposit.Find(); err != nil {
+		return nil, err
+	}
+	if deposit.StudentId != uint(studentId) {
+		return nil, swapErr.ErrBadData
+	}
+	return deposit, nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetFeeRevisions(c echo.Context) error {
+	batchStandard, err := GetBatchStandardParam(c)
+	if err != nil {
+		fmt.Println("GetBatchStandardParam(GetFeeRevisions)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	fr := &models.FeeRevision{}
+	feeRevisions, err := fr.All(batchStandard.ID)
+	if err != nil {
+		fmt.Println("fr.All(GetFeeRevisions)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, feeRevisions)
+}
+
+func CreateFeeRevision(c echo.Context) error {
+	cc := c.(CustomContext)
+	batchStandard, err := GetBatchStandardParam(c)
+	if err != nil {
+		fmt.Println("GetBatchStandardParam(CreateFeeRevision)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	feeRevisionData := make(map[string]interface{})
+	if err := c.Bind(&feeRevisionData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	feeRevision := models.NewFeeRevision(feeRevisionData, batchStandard)
+	feeRevision.UserID = cc.session.UserID
+	if err := feeRevision.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+
+	if err := feeRevision.Create(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Fee revision created", "fee_revision": feeRevision})
+}
+
+func PreviewFeeRevision(c echo.Context) error {
+	feeRevision, err := GetFeeRevision(c)
+	if err != nil {
+		fmt.Println("GetFeeRevision(PreviewFeeRevision)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	impacts, err := feeRevision.Preview()
+	if err != nil {
+		fmt.Println("fr.Preview(PreviewFeeRevision)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	total := 0.0
+	for _, impact := range impacts {
+		total = total + impact.Difference
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"fee_revision": feeRevision, "students": impacts, "total": total})
+}
+
+func ApplyFeeRevision(c echo.Context) error {
+	cc := c.(CustomContext)
+	feeRevision, err := GetFeeRevision(c)
+	if err != nil {
+		fmt.Println("GetFeeRevision(ApplyFeeRevision)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	applyData := make(map[string]interface{})
+	if err := c.Bind(&applyData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	rebillEnrolled, ok := applyData["rebill_enrolled"].(bool)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	impacts, err := feeRevision.Apply(rebillEnrolled, cc.session.UserID)
+	if err == swapErr.ErrFeeRevisionApplied || err == swapErr.ErrFeeRevisionNotDue {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err == swapErr.ErrFeeRevisionSuperseded || err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("fr.Apply(ApplyFeeRevision)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Fee revision applied", "fee_revision": feeRevision, "students": impacts})
+}
+
+func GetBatchStandardParam(c echo.Context) (*models.BatchStandard, error) {
+	batchId, err := strconv.Atoi(c.Param("batch_id"))
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	batchStandard := &models.BatchStandard{ID: uint(id)}
+	if err := batchStandard.Find(); err != nil {
+		return nil, err
+	}
+	if batchStandard.BatchId != uint(batchId) {
+		return nil, swapErr.ErrBadData
+	}
+	return batchStandard, nil
+}
+
+func GetFeeRevision(c echo.Context) (*models.FeeRevision, error) {
+	batchStandard, err := GetBatchStandardParam(c)
+	if err != nil {
+		return nil, err
+	}
+	revisionId, err := strconv.Atoi(c.Param("revision_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	feeRevision := &models.FeeRevision{ID: uint(revisionId)}
+	if err := feeRevision.Find(); err != nil {
+		return nil, err
+	}
+	if feeRevision.BatchStandardId != batchStandard.ID {
+		return nil, swapErr.ErrBadData
+	}
+	return feeRevision, nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetFiscalYears(c echo.Context) error {
+	fy := &models.FiscalYear{}
+	lq, err := GetListQuery(c, models.FiscalYearListFields, "-start_date", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	fiscalYears, err := fy.All(lq)
+	if err != nil {
+		fmt.Println("fy.All(GetFiscalYears)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	count, err := fy.Count(lq)
+	if err != nil {
+		fmt.Println("fy.All(GetFiscalYears)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, fiscalYears, count)
+}
+
+func CreateFiscalYear(c echo.Context) error {
+	fiscalYearData := make(map[string]interface{})
+	if err := c.Bind(&fiscalYearData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	fiscalYear := models.NewFiscalYear(fiscalYearData)
+	if err := fiscalYear.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+
+	err := fiscalYear.Create()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "fiscal year created", "fiscal_year": fiscalYear})
+}
+
+func CloseFiscalYear(c echo.Context) error {
+	cc := c.(CustomContext)
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	fiscalYear := &models.FiscalYear{ID: uint(newId)}
+	if err := fiscalYear.Find(); err != nil {
+		fmt.Println("fy.Find(CloseFiscalYear)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	next, err := fiscalYear.Close(cc.session.UserID)
+	if err == swapErr.ErrFiscalYearClosed || err == swapErr.ErrFiscalYearNotEnded || err == swapErr.ErrPreviousFiscalYearOpen {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("fy.Close(CloseFiscalYear)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "fiscal year closed", "fiscal_year": fiscalYear, "next_fiscal_year": next})
+}
+
+func GetFiscalYearReport(c echo.Context) error {
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	fiscalYear := &models.FiscalYear{ID: uint(newId)}
+	if err := fiscalYear.Find(); err != nil {
+		fmt.Println("fy.Find(GetFiscalYearReport)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	balances, err := fiscalYear.Balances()
+	if err != nil {
+		fmt.Println("fy.Balances(GetFiscalYearReport)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	var opening, debits, credits, closing float64
+	for _, balance := range balances {
+		opening = opening + balance.OpeningBalance
+		debits = debits + balance.Debits
+		credits = credits + balance.Credits
+		closing = closing + balance.ClosingBalance
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"fiscal_year": fiscalYear, "balances": balances,
+		"opening_balance": opening, "debits": debits, "credits": credits, "closing_balance": closing})
+}
+
+func GetFiscalYearParam(c echo.Context) (*models.FiscalYear, error) {
+	id := c.QueryParam("fiscal_year_id")
+	if id == "" {
+		return nil, nil
+	}
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fiscalYear := &models.FiscalYear{ID: uint(newId)}
+	err = fiscalYear.Find()
+	return fiscalYear, err
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetStudentGuardians(c echo.Context) error {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	g := &models.Guardian{}
+	guardians, err := g.All(uint(studentId))
+	if err != nil {
+		fmt.Println("g.All(GetStudentGuardians)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, guardians)
+}
+
+// AddStudentGuardian links an existing guardian when guardian_id is given,
+// as for a sibling, and creates a new guardian otherwise.
+func AddStudentGuardian(c echo.Context) error {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(studentId)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	guardianData := make(map[string]interface{})
+	if err := c.Bind(&guardianData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	isPrimary, _ := guardianData["is_primary"].(bool)
+
+	var guardian *models.Guardian
+	if guardianId, ok := guardianData["guardian_id"].(float64); ok {
+		guardian = &models.Guardian{ID: uint(guardianId)}
+		if err := guardian.Find(); err != nil {
+			fmt.Println("g.Find(AddStudentGuardian)", err)
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+		}
+	} else {
+		guardian = models.NewGuardian(guardianData)
+		if err := guardian.Validate(); err != nil {
+			formErr := MarshalFormError(err)
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+		}
+		if err := guardian.Create(); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+		}
+	}
+
+	if err := guardian.Link(student.ID, isPrimary); err != nil {
+		fmt.Println("g.Link(AddStudentGuardian)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Guardian added", "guardian": guardian})
+}
+
+func SetStudentPrimaryGuardian(c echo.Context) error {
+	studentId, guardian, err := studentGuardianParams(c)
+	if err != nil {
+		fmt.Println("studentGuardianParams(SetStudentPrimaryGuardian)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	if err := guardian.SetPrimary(studentId); err != nil {
+		fmt.Println("g.SetPrimary(SetStudentPrimaryGuardian)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	guardian.IsPrimary = true
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Primary contact changed", "guardian": guardian})
+}
+
+func RemoveStudentGuardian(c echo.Context) error {
+	studentId, guardian, err := studentGuardianParams(c)
+	if err != nil {
+		fmt.Println("studentGuardianParams(RemoveStudentGuardian)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	if err := guardian.Unlink(studentId); err != nil {
+		fmt.Println("g.Unlink(RemoveStudentGuardian)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Guardian removed"})
+}
+
+func UpdateGuardian(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	guardian := &models.Guardian{ID: uint(id)}
+	if err := guardian.Find(); err != nil {
+		fmt.Println("g.Find(UpdateGuardian)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	guardianData := make(map[string]interface{})
+	if err := c.Bind(&guardianData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	guardian.Assign(guardianData)
+	if err := guardian.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := guardian.Update(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Guardian updated", "guardian": guardian})
+}
+
+func GetGuardianStudents(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	guardian := &models.Guardian{ID: uint(id)}
+	students, err := guardian.Students()
+	if err != nil {
+		fmt.Println("g.Students(GetGuardianStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, students)
+}
+
+func studentGuardianParams(c echo.Context) (uint, *models.Guardian, error) {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	guardian := &models.Guardian{ID: uint(id)}
+	return uint(studentId), guardian, guardian.Find()
+}
@@ -13,13 +13,21 @@ import (
 func GetHostels(c echo.Context) error {
 	// Get all users
 	b := &models.Hostel{}
-	hostels, err := b.All()
+	lq, err := GetListQuery(c, models.HostelListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	hostels, err := b.All(lq)
 	if err != nil {
 		fmt.Println("s.ALL(GetHostels)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-
-	return c.JSON(http.StatusOK, hostels)
+	count, err := b.Count(lq)
+	if err != nil {
+		fmt.Println("s.ALL(GetHostels)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, hostels, count)
 }
 
 func GetHostel(c echo.Context) error {
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetHostelBillingPreview(c echo.Context) error {
+	date, hostelId, err := hostelBillingParams(c.QueryParam("date"), c.QueryParam("hostel_id"))
+	if err != nil {
+		fmt.Println("hostelBillingParams failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	charges, err := models.BillHostelStudents(date, hostelId, true)
+	if err != nil {
+		fmt.Println("models.BillHostelStudents(GetHostelBillingPreview)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"charges": charges, "total": len(charges)})
+}
+
+func RunHostelBilling(c echo.Context) error {
+	billingData := make(map[string]interface{})
+	if err := c.Bind(&billingData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	date, hostelId := "", ""
+	if billingDate, ok := billingData["date"].(string); ok {
+		date = billingDate
+	}
+	if billingHostelId, ok := billingData["hostel_id"].(float64); ok {
+		hostelId = strconv.Itoa(int(billingHostelId))
+	}
+
+	billingDate, billingHostelId, err := hostelBillingParams(date, hostelId)
+	if err != nil {
+		fmt.Println("hostelBillingParams failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	charges, err := models.BillHostelStudents(billingDate, billingHostelId, false)
+	if err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.BillHostelStudents(RunHostelBilling)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Hostel charges posted", "charges": charges, "total": len(charges)})
+}
+
+func hostelBillingParams(date string, hostelId string) (time.Time, uint, error) {
+	billingDate := time.Now()
+	if date != "" {
+		var err error
+		billingDate, err = time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return billingDate, 0, err
+		}
+	}
+
+	if hostelId == "" {
+		return billingDate, 0, nil
+	}
+	newHostelId, err := strconv.Atoi(hostelId)
+	return billingDate, uint(newHostelId), err
+}
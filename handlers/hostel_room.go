@@ -20,13 +20,21 @@ func GetHostelRooms(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
-	hostelRooms, err := hostel.HostelRooms()
+	lq, err := GetListQuery(c, models.HostelRoomListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	hostelRooms, err := hostel.HostelRooms(lq)
 	if err != nil {
 		fmt.Println("s.Find(GetHostelRoom)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-
-	return c.JSON(http.StatusOK, hostelRooms)
+	count, err := hostel.CountHostelRooms(lq)
+	if err != nil {
+		fmt.Println("s.Find(GetHostelRoom)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, hostelRooms, count)
 }
 
 func GetHostelRoom(c echo.Context) error {
@@ -99,7 +107,9 @@ func CreateHostelRoom(c echo.Context) error {
 	fmt.Printf("hostelRooms %+v\n", hostelRoomData)
 	hostelRoom := models.NewHostelRoom(hostelRoomData)
 	hostelRoom.HostelID = hostel.ID
-	hostelRoom.Rate = hostel.Rate
+	if hostelRoom.Rate <= 0.0 {
+		hostelRoom.Rate = hostel.Rate
+	}
 	if err := hostelRoom.Validate(); err != nil {
 		formErr := MarshalFormError(err)	
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
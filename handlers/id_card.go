@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/idcard"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetStudentIdCard prints the card of a student, as PDF unless format=png.
+func GetStudentIdCard(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "png" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	student := &models.Student{ID: uint(id)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	card, err := student.IdCard()
+	if err != nil {
+		fmt.Println("s.IdCard(GetStudentIdCard)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/pdf"
+	if format == "png" {
+		contentType = "image/png"
+		err = idcard.DefaultTemplate.PNG(card, &buf)
+	} else {
+		err = idcard.DefaultTemplate.PDF(card, &buf)
+	}
+	if err != nil {
+		fmt.Println("idcard.Render(GetStudentIdCard)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=id-card-%d.%s", student.ID, format))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+// GetBatchStandardIdCards prints the cards of a whole class on A4 sheets.
+func GetBatchStandardIdCards(c echo.Context) error {
+	batchStandard, err := GetBatchStandardParam(c)
+	if err != nil {
+		fmt.Println("GetBatchStandardParam(GetBatchStandardIdCards)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	cards, err := batchStandard.IdCards()
+	if err != nil {
+		fmt.Println("bs.IdCards(GetBatchStandardIdCards)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	var buf bytes.Buffer
+	if err := idcard.DefaultTemplate.Sheets(cards, &buf); err != nil {
+		fmt.Println("idcard.Sheets(GetBatchStandardIdCards)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=id-cards-%d.pdf", batchStandard.ID))
+	return c.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// VerifyIdCard resolves the token scanned from a card to the student. A
+// genuine card of a student who has left, or an expired one, is not valid.
+func VerifyIdCard(c echo.Context) error {
+	studentId, issuedAt, err := models.VerifyIdCardToken(c.QueryParam("token"))
+	if err == swapErr.ErrInvalidIdCard {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"valid": false, "message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.VerifyIdCardToken(VerifyIdCard)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	student := &models.Student{ID: studentId}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(VerifyIdCard)", err)
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"valid": false, "message": "Student not found"})
+	}
+	if err := student.CheckIdCard(issuedAt); err != nil {
+		return c.JSON(http.StatusOK, map[string]interface{}{"valid": false, "message": err.Error(), "issued_at": issuedAt,
+			"student": student})
+	}
+	card, err := student.IdCardDetails()
+	if err != nil {
+		fmt.Println("s.IdCardDetails(VerifyIdCard)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"valid": true, "issued_at": issuedAt, "student": student,
+		"class": card.Class, "roll_number": card.RollNumber, "hostel": card.Hostel, "blood_group": card.BloodGroup,
+		"emergency_contact": card.EmergencyContact})
+}
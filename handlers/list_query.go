@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetListQuery parses the filter, sort, page, page_size and fields params of
+// a list request against the fields the model allows.
+func GetListQuery(c echo.Context, fields models.ListFields, defaultSort string, defaultPageSize int) (*models.ListQuery, error) {
+	return models.ParseListQuery(c.QueryParams(), fields, defaultSort, defaultPageSize)
+}
+
+// ListJSON writes the selected fields of list, with the number of matching
+// records in the X-Total-Count header.
+func ListJSON(c echo.Context, lq *models.ListQuery, list interface{}, total int64) error {
+	picked, err := lq.Pick(list)
+	if err != nil {
+		fmt.Println("lq.Pick(ListJSON)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	c.Response().Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	return c.JSON(http.StatusOK, picked)
+}
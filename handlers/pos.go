@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetPosItems(c echo.Context) error {
+	pi := &models.PosItem{}
+	lq, err := GetListQuery(c, models.PosItemListFields, "name", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	posItems, err := pi.All(lq, c.QueryParam("category"))
+	if err != nil {
+		fmt.Println("pi.All(GetPosItems)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	count, err := pi.Count(lq, c.QueryParam("category"))
+	if err != nil {
+		fmt.Println("pi.All(GetPosItems)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, posItems, count)
+}
+
+func GetLowStockPosItems(c echo.Context) error {
+	pi := &models.PosItem{}
+	posItems, err := pi.LowStock()
+	if err != nil {
+		fmt.Println("pi.LowStock(GetLowStockPosItems)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, posItems)
+}
+
+func CreatePosItem(c echo.Context) error {
+	posItemData := make(map[string]interface{})
+	if err := c.Bind(&posItemData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	posItem := models.NewPosItem(posItemData)
+	if err := posItem.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+
+	err := posItem.Create()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "pos item created", "pos_item": posItem})
+}
+
+func UpdatePosItem(c echo.Context) error {
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	posItemData := make(map[string]interface{})
+	if err := c.Bind(&posItemData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	posItem := &models.PosItem{ID: uint(newId)}
+	if err := posItem.Find(); err != nil {
+		fmt.Println("pi.Find(UpdatePosItem)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	posItem.Assign(posItemData)
+	if err := posItem.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := posItem.Update(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "pos item updated", "pos_item": posItem})
+}
+
+func RestockPosItem(c echo.Context) error {
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	restockData := make(map[string]interface{})
+	if err := c.Bind(&restockData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	quantity, ok := restockData["quantity"].(float64)
+	if !ok || quantity < 1 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	posItem := &models.PosItem{ID: uint(newId)}
+	if err := posItem.Find(); err != nil {
+		fmt.Println("pi.Find(RestockPosItem)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	if err := posItem.Restock(int(quantity)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "pos item restocked", "pos_item": posItem})
+}
+
+func GetStudentPosSales(c echo.Context) error {
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(newStudentId)}
+	err = student.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	ps := &models.PosSale{}
+	posSales, err := ps.All(student.ID)
+	if err != nil {
+		fmt.Println("ps.All(GetStudentPosSales)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, posSales)
+}
+
+func CreateStudentPosSale(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(newStudentId)}
+	err = student.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	posSaleData := make(map[string]interface{})
+	if err := c.Bind(&posSaleData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	posSale, err := models.NewPosSale(posSaleData, student)
+	if err != nil {
+		fmt.Println("models.NewPosSale(CreateStudentPosSale)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	posSale.UserID = cc.session.UserID
+
+	err = posSale.Create()
+	if err == swapErr.ErrInsufficientBalance || err == swapErr.ErrOutOfStock {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("ps.Create(CreateStudentPosSale)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "sale created", "pos_sale": posSale})
+}
+
+func GetPosSalesSummary(c echo.Context) error {
+	date := time.Now()
+	if day := c.QueryParam("date"); day != "" {
+		var err error
+		date, err = time.ParseInLocation("2006-01-02", day, time.Local)
+		if err != nil {
+			fmt.Println("time.Parse failed", err)
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+		}
+	}
+
+	ps := &models.PosSale{}
+	items, count, total, err := ps.DailySummary(date)
+	if err != nil {
+		fmt.Println("ps.DailySummary(GetPosSalesSummary)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	pi := &models.PosItem{}
+	lowStock, err := pi.LowStock()
+	if err != nil {
+		fmt.Println("pi.LowStock(GetPosSalesSummary)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"date": date.Format("2006-01-02"), "sales": count, "total": total,
+		"items": items, "low_stock": lowStock})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetPromotions(c echo.Context) error {
+	p := &models.Promotion{}
+	promotions, err := p.All()
+	if err != nil {
+		fmt.Println("p.All(GetPromotions)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, promotions)
+}
+
+func GetPromotion(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	promotion := &models.Promotion{ID: uint(id)}
+	if err := promotion.Find(); err != nil {
+		fmt.Println("p.Find(GetPromotion)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	report, err := promotion.GetReport()
+	if err != nil {
+		fmt.Println("p.GetReport(GetPromotion)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"promotion": promotion, "report": report})
+}
+
+func PreviewPromotion(c echo.Context) error {
+	return promote(c, true)
+}
+
+func CreatePromotion(c echo.Context) error {
+	return promote(c, false)
+}
+
+func promote(c echo.Context, dryRun bool) error {
+	cc := c.(CustomContext)
+	options, err := GetPromotionOptions(c)
+	if err != nil {
+		fmt.Println("GetPromotionOptions failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	options.DryRun = dryRun
+
+	report, promotion, err := models.PromoteStudents(*options, cc.session.UserID)
+	if err == swapErr.ErrPromotionMapping || err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.PromoteStudents(promote)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	if dryRun {
+		return c.JSON(http.StatusOK, report)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "students promoted", "promotion": promotion, "report": report})
+}
+
+// GetPromotionOptions reads {"mappings": [{"from_batch_standard_id",
+// "to_batch_standard_id"}], "exclude_student_ids", "carry_forward", "reason"}.
+func GetPromotionOptions(c echo.Context) (*models.PromotionOptions, error) {
+	promotionData := make(map[string]interface{})
+	if err := c.Bind(&promotionData); err != nil {
+		return nil, err
+	}
+
+	options := &models.PromotionOptions{}
+	mappings, _ := promotionData["mappings"].([]interface{})
+	for _, mapping := range mappings {
+		mappingData, ok := mapping.(map[string]interface{})
+		if !ok {
+			return nil, swapErr.ErrBadData
+		}
+		from, okFrom := mappingData["from_batch_standard_id"].(float64)
+		to, okTo := mappingData["to_batch_standard_id"].(float64)
+		if !okFrom || !okTo {
+			return nil, swapErr.ErrBadData
+		}
+		options.Mappings = append(options.Mappings, models.PromotionMapping{FromBatchStandardId: uint(from), ToBatchStandardId: uint(to)})
+	}
+
+	excluded, _ := promotionData["exclude_student_ids"].([]interface{})
+	for _, id := range excluded {
+		studentId, ok := id.(float64)
+		if !ok {
+			return nil, swapErr.ErrBadData
+		}
+		options.ExcludeStudentIds = append(options.ExcludeStudentIds, uint(studentId))
+	}
+	options.CarryForward, _ = promotionData["carry_forward"].(bool)
+	options.Reason, _ = promotionData["reason"].(string)
+	return options, nil
+}
@@ -13,13 +13,21 @@ import (
 func GetStandards(c echo.Context) error {
 	// Get all users
 	s := &models.Standard{}
-	standards, err := s.All()
+	lq, err := GetListQuery(c, models.StandardListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	standards, err := s.All(lq)
 	if err != nil {
 		fmt.Println("s.ALL(GetStandards)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-
-	return c.JSON(http.StatusOK, standards)
+	count, err := s.Count(lq)
+	if err != nil {
+		fmt.Println("s.ALL(GetStandards)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, standards, count)
 }
 
 func GetStandard(c echo.Context) error {
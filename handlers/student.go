@@ -6,33 +6,44 @@ import (
 	"strconv"
 	"swapnil-ex/models"
 	"swapnil-ex/swapErr"
+	"time"
 	"github.com/labstack/echo/v4"
+	"gopkg.in/validator.v2"
 )
 
 func GetStudents(c echo.Context) error {
 	// Get all users
 	s := &models.Student{}
 
-	page := c.QueryParam("page")
-	newPage, err := strconv.Atoi(page)
+	fields, err := models.StudentListFieldsWithCustom()
 	if err != nil {
-		newPage = 1
+		fmt.Println("models.StudentListFieldsWithCustom(GetStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	lq, err := GetListQuery(c, fields, "", 10)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
-	search := c.QueryParam("search")
+	search, fuzzy, tags := models.StudentListParams(c.QueryParams())
 
-	students, err := s.All(int(newPage), search)
+	students, err := s.All(lq, search, fuzzy, tags)
 	if err != nil {
 		fmt.Println("s.ALL(GetStudents)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
-	count, err := s.Count(search)
+	count, err := s.Count(lq, search, fuzzy, tags)
 	if err != nil {
 		fmt.Println("s.ALL(GetStudents)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{"students": students, "total": count})
+	picked, err := lq.Pick(students)
+	if err != nil {
+		fmt.Println("lq.Pick(GetStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"students": picked, "total": count})
 }
 
 func GetStudent(c echo.Context) error {
@@ -68,6 +79,17 @@ func CreateStudent(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
 	}
 
+	if ignore, _ := studentData["ignore_duplicates"].(bool); !ignore {
+		duplicates, err := student.FindDuplicates()
+		if err != nil {
+			fmt.Println("s.FindDuplicates(CreateStudent)", err)
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+		}
+		if len(duplicates) > 0 {
+			return c.JSON(http.StatusConflict, map[string]interface{}{"error": swapErr.ErrDuplicateStudent.Error(), "duplicates": duplicates})
+		}
+	}
+
 	err := student.Create()
 	if err != nil {
 		
@@ -123,7 +145,11 @@ func DeleteStudent(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
-	if err := s.Delete(); err != nil {
+	err = s.Delete()
+	if err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
 		fmt.Println("s.Delete(GetStudent)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
@@ -200,6 +226,7 @@ func AssignStudentHostel(c echo.Context) error {
 }
 
 func ChangeStudentHostel(c echo.Context) error {
+	cc := c.(CustomContext)
 	studentHostelData := make(map[string]interface{})
 	if err := c.Bind(&studentHostelData); err != nil {
 		fmt.Println("c.Bind()", err)
@@ -213,6 +240,16 @@ func ChangeStudentHostel(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
 	}
 
+	effectiveOn := time.Now()
+	if date, ok := studentHostelData["effective_on"].(string); ok {
+		effectiveOn, err = time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			fmt.Println("time.Parse failed", err)
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+		}
+	}
+	reason, _ := studentHostelData["reason"].(string)
+
 	s := &models.Student{ID: uint(newId)}
 	err = s.Find()
 	if err != nil {
@@ -233,10 +270,204 @@ func ChangeStudentHostel(c echo.Context) error {
 		fmt.Println("s.Find(GetHostelRoom)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	err = s.ChangeHostel(hostel, hostelRoom)
+	transfer, err := s.TransferHostel(hostel, hostelRoom, effectiveOn, cc.session.UserID, reason)
+	if err == swapErr.ErrRoomFull || err == swapErr.ErrRoomNotInHostel || err == swapErr.ErrAlreadyInRoom ||
+		err == swapErr.ErrNoBillingCycle {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
 	if err != nil {
 		fmt.Println("s.Find(ChangeHostelStudent)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Student assigned to hostel", "student": s})
-}
\ No newline at end of file
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Student assigned to hostel", "student": s, "hostel_transfer": transfer})
+}
+
+func GetStudentHostelTransfers(c echo.Context) error {
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	s := &models.Student{ID: uint(newId)}
+	err = s.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	ht := &models.HostelTransfer{}
+	hostelTransfers, err := ht.All(s.ID)
+	if err != nil {
+		fmt.Println("ht.All(GetStudentHostelTransfers)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, hostelTransfers)
+}
+
+func LeaveStudentHostel(c echo.Context) error {
+	studentHostelData := make(map[string]interface{})
+	if err := c.Bind(&studentHostelData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	leftOn := time.Now()
+	if date, ok := studentHostelData["left_on"].(string); ok {
+		leftOn, err = time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			fmt.Println("time.Parse failed", err)
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+		}
+	}
+
+	s := &models.Student{ID: uint(newId)}
+	err = s.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	err = s.LeaveHostel(leftOn)
+	if err != nil {
+		fmt.Println("s.LeaveHostel(LeaveStudentHostel)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Student left hostel", "student": s})
+}
+
+func GetStudentStatusChanges(c echo.Context) error {
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	s := &models.Student{ID: uint(newId)}
+	statusChanges, err := s.GetStatusChanges()
+	if err != nil {
+		fmt.Println("s.GetStatusChanges(GetStudentStatusChanges)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, statusChanges)
+}
+
+func ChangeStudentStatus(c echo.Context) error {
+	cc := c.(CustomContext)
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	statusData := make(map[string]interface{})
+	if err := c.Bind(&statusData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	status, _ := statusData["status"].(string)
+	reason, _ := statusData["reason"].(string)
+
+	s := &models.Student{ID: uint(newId)}
+	if err := s.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	statusChange, err := s.Transition(status, reason, cc.session.UserID)
+	if err == swapErr.ErrInvalidStatusTransition || err == swapErr.ErrBalanceNotCleared || err == swapErr.ErrNoClassAssigned {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if _, ok := err.(validator.ErrorMap); ok {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err != nil {
+		fmt.Println("s.Transition(ChangeStudentStatus)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "student status changed", "student": s, "status_change": statusChange})
+}
+
+func ChangeStudentsStatus(c echo.Context) error {
+	cc := c.(CustomContext)
+	statusData := make(map[string]interface{})
+	if err := c.Bind(&statusData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	status, _ := statusData["status"].(string)
+	reason, _ := statusData["reason"].(string)
+	ids, ok := statusData["student_ids"].([]interface{})
+	if !ok || len(ids) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	studentIds := []uint{}
+	for _, id := range ids {
+		studentId, ok := id.(float64)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+		}
+		studentIds = append(studentIds, uint(studentId))
+	}
+
+	results := models.TransitionStudents(studentIds, status, reason, cc.session.UserID)
+	changed := 0
+	for _, result := range results {
+		if result.StatusChange != nil {
+			changed = changed + 1
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "students status changed", "students": results, "changed": changed})
+}
+
+func RevealStudentAadhaar(c echo.Context) error {
+	cc := c.(CustomContext)
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	s := &models.Student{ID: uint(newId)}
+	if err := s.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	adharCard, err := s.RevealAadhaar(cc.session.UserID, c.RealIP())
+	if err != nil {
+		fmt.Println("s.RevealAadhaar(RevealStudentAadhaar)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"student_id": s.ID, "adhar_card": adharCard})
+}
+
+func GetStudentAadhaarAccessLogs(c echo.Context) error {
+	id := c.Param("id")
+	newId, err := strconv.Atoi(id)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	s := &models.Student{ID: uint(newId)}
+	accessLogs, err := s.GetAadhaarAccessLogs()
+	if err != nil {
+		fmt.Println("s.GetAadhaarAccessLogs(GetStudentAadhaarAccessLogs)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, accessLogs)
+}
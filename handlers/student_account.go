@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"swapnil-ex/models"
 	"swapnil-ex/swapErr"
 
@@ -25,13 +26,60 @@ func GetStudentAccounts(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
+	lq, err := GetListQuery(c, models.StudentAccountListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
 	studentAccount := &models.StudentAccount{}
-	studentAccounts, err := studentAccount.All(newStudentId)
+	studentAccounts, err := studentAccount.All(lq, newStudentId)
 	if err != nil {
 		fmt.Println("s.ALL(GetStudentAccounts)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, studentAccounts)
+	count, err := studentAccount.Count(lq, []uint{student.ID})
+	if err != nil {
+		fmt.Println("sa.Count(GetStudentAccounts)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, studentAccounts, count)
+}
+
+func GetAllStudentAccounts(c echo.Context) error {
+	sa := &models.StudentAccount{}
+	s := &models.Student{}
+	lq, err := GetListQuery(c, models.StudentAccountListFields, "-id", 10)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	search := c.QueryParam("search")
+	fuzzy, _ := strconv.ParseBool(c.QueryParam("fuzzy"))
+	err, ids := s.SearchIds(search, fuzzy)
+	if err != nil {
+		fmt.Println("s.SearchIds(GetAllStudentAccounts)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	if strings.TrimSpace(search) != "" && len(ids) == 0 {
+		return c.JSON(http.StatusOK, map[string]interface{}{"student_accounts": []models.StudentAccount{}, "total": 0})
+	}
+
+	studentAccounts, err := sa.AllStudentAccounts(lq, ids)
+	if err != nil {
+		fmt.Println("sa.AllStudentAccounts(GetAllStudentAccounts)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	count, err := sa.Count(lq, ids)
+	if err != nil {
+		fmt.Println("sa.Count(GetAllStudentAccounts)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	picked, err := lq.Pick(studentAccounts)
+	if err != nil {
+		fmt.Println("lq.Pick(GetAllStudentAccounts)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"student_accounts": picked, "total": count})
 }
 
 func GetStudentAccountBalance(c echo.Context) error {
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"swapnil-ex/models"
 	"swapnil-ex/swapErr"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -102,6 +103,83 @@ func CreateStudentBatchStandard(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{"message": "batch Standard Student created", "batch_standard": batchStandard})	
 }
 
+func TransferStudentBatchStandard(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	transferData := make(map[string]interface{})
+	if err := c.Bind(&transferData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	effectiveOn := time.Now()
+	if date, ok := transferData["effective_on"].(string); ok {
+		effectiveOn, err = time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			fmt.Println("time.Parse failed", err)
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+		}
+	}
+	feeMode := models.TransferFeeProrated
+	if mode, ok := transferData["fee_mode"].(string); ok && mode != "" {
+		feeMode = mode
+	}
+	if feeMode != models.TransferFeeProrated && feeMode != models.TransferFeeFull {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	reason, _ := transferData["reason"].(string)
+
+	student := &models.Student{ID: uint(newStudentId)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	batchStandard := &models.BatchStandard{ID: models.GetBatchStandardId(transferData)}
+	if err := batchStandard.Find(); err != nil {
+		fmt.Println("s.Find(GetBatchStandard)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	transfer, err := student.TransferBatchStandard(batchStandard, feeMode, effectiveOn, cc.session.UserID, reason)
+	if err == swapErr.ErrNoClassAssigned || err == swapErr.ErrAlreadyInClass || err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("s.TransferBatchStandard(TransferStudentBatchStandard)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Student transferred", "student": student, "batch_standard_transfer": transfer})
+}
+
+func GetStudentBatchStandardTransfers(c echo.Context) error {
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(newStudentId)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	bst := &models.BatchStandardTransfer{}
+	transfers, err := bst.All(student.ID)
+	if err != nil {
+		fmt.Println("bst.All(GetStudentBatchStandardTransfers)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, transfers)
+}
+
 func UpdateStudentBatchStandard(c echo.Context) error {
 	batchId := c.Param("batch_id")
 	newBatchId, err := strconv.Atoi(batchId)
@@ -181,7 +259,7 @@ func GetStudentBatchStandards(c echo.Context) error {
 	}
 
 	bs := &models.BatchStandard{}
-	batchStandards, err := bs.All(b.ID)
+	batchStandards, err := bs.All(&models.ListQuery{}, b.ID)
 	if err != nil {
 		fmt.Println("s.ALL(GetBatchs)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/validator.v2"
+)
+
+func GetStudentDocuments(c echo.Context) error {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	sd := &models.StudentDocument{}
+	documents, err := sd.All(uint(studentId))
+	if err != nil {
+		fmt.Println("sd.All(GetStudentDocuments)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, documents)
+}
+
+// UploadStudentDocument takes a multipart upload with the file in "file" and
+// its category in "category".
+func UploadStudentDocument(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(studentId)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		fmt.Println("c.FormFile(UploadStudentDocument)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		fmt.Println("fileHeader.Open(UploadStudentDocument)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	defer file.Close()
+
+	document := &models.StudentDocument{StudentId: student.ID, Category: c.FormValue("category"),
+		FileName: fileHeader.Filename, UserID: cc.session.UserID}
+	err = document.Upload(file)
+	if err == swapErr.ErrDocumentTooLarge || err == swapErr.ErrDocumentType {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if _, ok := err.(validator.ErrorMap); ok {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err != nil {
+		fmt.Println("sd.Upload(UploadStudentDocument)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Document uploaded", "document": document})
+}
+
+func DownloadStudentDocument(c echo.Context) error {
+	document, err := GetStudentDocument(c)
+	if err != nil {
+		fmt.Println("GetStudentDocument(DownloadStudentDocument)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	file, err := document.Open()
+	if err != nil {
+		fmt.Println("sd.Open(DownloadStudentDocument)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	defer file.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Response().Header().Set("Digest", "sha-256="+document.Checksum)
+	return c.Stream(http.StatusOK, document.ContentType, file)
+}
+
+func DeleteStudentDocument(c echo.Context) error {
+	document, err := GetStudentDocument(c)
+	if err != nil {
+		fmt.Println("GetStudentDocument(DeleteStudentDocument)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	if err := document.Delete(); err != nil {
+		fmt.Println("sd.Delete(DeleteStudentDocument)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Document deleted"})
+}
+
+func GetMissingDocumentsReport(c echo.Context) error {
+	batchStandard, err := GetBatchStandardParam(c)
+	if err != nil {
+		fmt.Println("GetBatchStandardParam(GetMissingDocumentsReport)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	report, err := batchStandard.MissingRequiredDocuments()
+	if err != nil {
+		fmt.Println("bs.MissingRequiredDocuments(GetMissingDocumentsReport)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"required": models.RequiredDocuments, "students": report, "total": len(report)})
+}
+
+func GetStudentDocument(c echo.Context) (*models.StudentDocument, error) {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	document := &models.StudentDocument{ID: uint(id)}
+	if err := document.Find(); err != nil {
+		return nil, err
+	}
+	if document.StudentId != uint(studentId) {
+		return nil, swapErr.ErrBadData
+	}
+	return document, nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"swapnil-ex/models"
+	"swapnil-ex/sheet"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ExportStudents streams the roster as csv (default), xlsx or jsonl.
+func ExportStudents(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" && format != "jsonl" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": sheet.ErrUnsupportedFormat.Error()})
+	}
+
+	filter, err := studentExportFilter(c)
+	if err != nil {
+		fmt.Println("studentExportFilter(ExportStudents)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	columns := []string{}
+	if value := c.QueryParam("columns"); value != "" {
+		for _, column := range strings.Split(value, ",") {
+			columns = append(columns, strings.TrimSpace(column))
+		}
+	}
+	if err := models.ValidateStudentExportColumns(columns); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, sheet.ContentType(format))
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=students.%s", format))
+	writer, err := sheet.NewWriter(format, response)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+
+	err = models.ExportStudents(filter, columns, writer)
+	if err != nil {
+		fmt.Println("models.ExportStudents(ExportStudents)", err)
+		if !response.Committed {
+			response.Header().Del(echo.HeaderContentDisposition)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+		}
+	}
+	return nil
+}
+
+func studentExportFilter(c echo.Context) (models.StudentExportFilter, error) {
+	_, _, tags := models.StudentListParams(c.QueryParams())
+	filter := models.StudentExportFilter{Status: c.QueryParam("status"), Town: c.QueryParam("town"), Tags: tags}
+	for name, id := range map[string]*uint{"batch_id": &filter.BatchId, "standard_id": &filter.StandardId, "hostel_id": &filter.HostelId} {
+		if value := c.QueryParam(name); value != "" {
+			newId, err := strconv.Atoi(value)
+			if err != nil {
+				return filter, err
+			}
+			*id = uint(newId)
+		}
+	}
+
+	for name, balance := range map[string]**float64{"min_balance": &filter.MinBalance, "max_balance": &filter.MaxBalance} {
+		if value := c.QueryParam(name); value != "" {
+			amount, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return filter, err
+			}
+			*balance = &amount
+		}
+	}
+
+	if value := c.QueryParam("segment_id"); value != "" {
+		segmentId, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Segment = &models.StudentSegment{ID: uint(segmentId)}
+		if err := filter.Segment.Find(); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
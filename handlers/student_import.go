@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/sheet"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportStudents takes a multipart upload with the CSV or XLSX in "file".
+// The report is returned without saving anything when dry_run is set.
+func ImportStudents(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		fmt.Println("c.FormFile(ImportStudents)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		fmt.Println("fileHeader.Open(ImportStudents)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	defer file.Close()
+
+	rows, err := sheet.Read(fileHeader.Filename, file)
+	if err != nil {
+		fmt.Println("sheet.Read(ImportStudents)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
+	options, err := studentImportOptions(c)
+	if err != nil {
+		fmt.Println("studentImportOptions(ImportStudents)", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	report, err := models.ImportStudents(rows, options)
+	if err == swapErr.ErrBadData {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.ImportStudents(ImportStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error(), "report": report})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func studentImportOptions(c echo.Context) (models.StudentImportOptions, error) {
+	options := models.StudentImportOptions{Mapping: map[string]string{}}
+	if mapping := c.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			return options, err
+		}
+	}
+
+	for name, id := range map[string]*uint{"batch_standard_id": &options.BatchStandardId, "hostel_id": &options.HostelId,
+		"hostel_room_id": &options.HostelRoomId} {
+		if value := c.FormValue(name); value != "" {
+			newId, err := strconv.Atoi(value)
+			if err != nil {
+				return options, err
+			}
+			*id = uint(newId)
+		}
+	}
+
+	options.FeeIncluded, _ = strconv.ParseBool(c.FormValue("fee_included"))
+	options.AllOrNothing, _ = strconv.ParseBool(c.FormValue("all_or_nothing"))
+	options.DryRun, _ = strconv.ParseBool(c.FormValue("dry_run"))
+	return options, nil
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetStudentDuplicates(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	s := &models.Student{ID: uint(id)}
+	if err := s.Find(); err != nil {
+		fmt.Println("s.Find(GetStudentDuplicates)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	duplicates, err := s.FindDuplicates()
+	if err != nil {
+		fmt.Println("s.FindDuplicates(GetStudentDuplicates)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, duplicates)
+}
+
+func MergeStudent(c echo.Context) error {
+	cc := c.(CustomContext)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	mergeData := make(map[string]interface{})
+	if err := c.Bind(&mergeData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	duplicateId, ok := mergeData["duplicate_id"].(float64)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	reason, _ := mergeData["reason"].(string)
+
+	s := &models.Student{ID: uint(id)}
+	if err := s.Find(); err != nil {
+		fmt.Println("s.Find(MergeStudent)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	duplicate := &models.Student{ID: uint(duplicateId)}
+	if err := duplicate.Find(); err != nil {
+		fmt.Println("duplicate.Find(MergeStudent)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	merge, err := s.Merge(duplicate, reason, cc.session.UserID)
+	if err == swapErr.ErrMergeSameStudent || err == swapErr.ErrMergeConflict {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("s.Merge(MergeStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "students merged", "student": s, "merge": merge})
+}
+
+func GetStudentMerges(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	s := &models.Student{ID: uint(id)}
+	merges, err := s.GetMerges()
+	if err != nil {
+		fmt.Println("s.GetMerges(GetStudentMerges)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, merges)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/validator.v2"
+)
+
+func GetStudentNotes(c echo.Context) error {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	sn := &models.StudentNote{}
+	notes, err := sn.All(uint(studentId))
+	if err != nil {
+		fmt.Println("sn.All(GetStudentNotes)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, notes)
+}
+
+func CreateStudentNote(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	studentNoteData := make(map[string]interface{})
+	if err := c.Bind(&studentNoteData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	student := &models.Student{ID: uint(studentId)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	note := &models.StudentNote{StudentId: student.ID, UserID: cc.session.UserID}
+	note.Assign(studentNoteData)
+	if err := note.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.(validator.ErrorMap)})
+	}
+	if err := note.Create(); err != nil {
+		fmt.Println("sn.Create(CreateStudentNote)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Note added", "note": note})
+}
+
+func DeleteStudentNote(c echo.Context) error {
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	note := &models.StudentNote{ID: uint(id)}
+	if err := note.Find(); err != nil || note.StudentId != uint(studentId) {
+		fmt.Println("sn.Find(DeleteStudentNote)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Note not found"})
+	}
+	if err := note.Delete(); err != nil {
+		fmt.Println("sn.Delete(DeleteStudentNote)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Note deleted"})
+}
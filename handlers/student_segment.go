@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetStudentSegments(c echo.Context) error {
+	ss := &models.StudentSegment{}
+	segments, err := ss.All()
+	if err != nil {
+		fmt.Println("ss.All(GetStudentSegments)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, segments)
+}
+
+func CreateStudentSegment(c echo.Context) error {
+	cc := c.(CustomContext)
+	segmentData := make(map[string]interface{})
+	if err := c.Bind(&segmentData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	segment := models.NewStudentSegment(segmentData)
+	segment.UserID = cc.session.UserID
+	if err := segment.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := segment.Create(); err != nil {
+		fmt.Println("ss.Create(CreateStudentSegment)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "segment created", "segment": segment})
+}
+
+func UpdateStudentSegment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	segment := &models.StudentSegment{ID: uint(id)}
+	if err := segment.Find(); err != nil {
+		fmt.Println("ss.Find(UpdateStudentSegment)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Segment not found"})
+	}
+	segmentData := make(map[string]interface{})
+	if err := c.Bind(&segmentData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	segment.Assign(segmentData)
+	if err := segment.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := segment.Update(); err != nil {
+		fmt.Println("ss.Update(UpdateStudentSegment)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "segment updated", "segment": segment})
+}
+
+func DeleteStudentSegment(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	segment := &models.StudentSegment{ID: uint(id)}
+	if err := segment.Find(); err != nil {
+		fmt.Println("ss.Find(DeleteStudentSegment)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Segment not found"})
+	}
+	if err := segment.Delete(); err != nil {
+		fmt.Println("ss.Delete(DeleteStudentSegment)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "segment deleted"})
+}
+
+// GetStudentSegmentStudents lists the students of a segment as GET /students
+// does; page, page_size, sort and fields may be given over the saved ones.
+func GetStudentSegmentStudents(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	segment := &models.StudentSegment{ID: uint(id)}
+	if err := segment.Find(); err != nil {
+		fmt.Println("ss.Find(GetStudentSegmentStudents)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Segment not found"})
+	}
+	lq, err := segment.ListQuery(c.QueryParams(), 10)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	students, count, err := segment.Students(lq)
+	if err != nil {
+		fmt.Println("ss.Students(GetStudentSegmentStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	picked, err := lq.Pick(students)
+	if err != nil {
+		fmt.Println("lq.Pick(GetStudentSegmentStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"students": picked, "total": count})
+}
+
+// GetStudentSegmentContacts lists the phone numbers to send a notification
+// to the students of a segment and their primary guardians.
+func GetStudentSegmentContacts(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	segment := &models.StudentSegment{ID: uint(id)}
+	if err := segment.Find(); err != nil {
+		fmt.Println("ss.Find(GetStudentSegmentContacts)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Segment not found"})
+	}
+	contacts, err := segment.Contacts()
+	if err != nil {
+		fmt.Println("ss.Contacts(GetStudentSegmentContacts)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, contacts)
+}
+
+func GetStudentSegmentReport(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	segment := &models.StudentSegment{ID: uint(id)}
+	if err := segment.Find(); err != nil {
+		fmt.Println("ss.Find(GetStudentSegmentReport)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Segment not found"})
+	}
+	report, err := segment.Report()
+	if err != nil {
+		fmt.Println("ss.Report(GetStudentSegmentReport)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetStudentTimeline lists the events of a student newest first, 20 per page
+// by default. types is a comma separated list of the event types to keep.
+func GetStudentTimeline(c echo.Context) error {
+	studentId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	lq, err := GetListQuery(c, models.TimelineListFields, "", 20)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	known := map[string]bool{}
+	for _, eventType := range models.TimelineTypes {
+		known[eventType] = true
+	}
+	types := []string{}
+	for _, eventType := range strings.Split(c.QueryParam("types"), ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		if !known[eventType] {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown event type " + eventType})
+		}
+		types = append(types, eventType)
+	}
+
+	student := &models.Student{ID: uint(studentId)}
+	if err := student.Find(); err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	events, total, err := student.Timeline(lq, types)
+	if err != nil {
+		fmt.Println("s.Timeline(GetStudentTimeline)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, events, total)
+}
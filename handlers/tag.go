@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTags lists the tags with their number of students; q narrows them to
+// names starting with it, for autocomplete.
+func GetTags(c echo.Context) error {
+	t := &models.Tag{}
+	tags, err := t.All(c.QueryParam("q"))
+	if err != nil {
+		fmt.Println("t.All(GetTags)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// UpdateTag renames a tag on every student having it.
+func UpdateTag(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	tagData := make(map[string]interface{})
+	if err := c.Bind(&tagData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	tag := &models.Tag{ID: uint(id)}
+	if err := tag.Find(); err != nil {
+		fmt.Println("t.Find(UpdateTag)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Tag not found"})
+	}
+	tag.Assign(tagData)
+	if err := tag.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	if err := tag.Update(); err != nil {
+		fmt.Println("t.Update(UpdateTag)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "tag updated", "tag": tag})
+}
+
+// DeleteTag removes a tag from every student.
+func DeleteTag(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	tag := &models.Tag{ID: uint(id)}
+	if err := tag.Find(); err != nil {
+		fmt.Println("t.Find(DeleteTag)", err)
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Tag not found"})
+	}
+	if err := tag.Delete(); err != nil {
+		fmt.Println("t.Delete(DeleteTag)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "tag deleted"})
+}
+
+func GetStudentTags(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(id)}
+	tags, err := student.GetTags()
+	if err != nil {
+		fmt.Println("s.GetTags(GetStudentTags)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// TagStudents puts tags on the students given by student_ids or on every
+// student of the segment segment_id.
+func TagStudents(c echo.Context) error {
+	cc := c.(CustomContext)
+	ids, tags, err := bulkTagParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	tagged, err := models.TagStudents(ids, tags, cc.session.UserID)
+	if err == swapErr.ErrNoTags || err == swapErr.ErrTagName {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.TagStudents(TagStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Students tagged", "tagged": tagged})
+}
+
+// UntagStudents takes tags off the students given as for TagStudents.
+func UntagStudents(c echo.Context) error {
+	ids, tags, err := bulkTagParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	untagged, err := models.UntagStudents(ids, tags)
+	if err == swapErr.ErrNoTags {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.UntagStudents(UntagStudents)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Students untagged", "untagged": untagged})
+}
+
+// bulkTagParams reads the students and the tag names of a bulk tag or untag.
+func bulkTagParams(c echo.Context) ([]uint, []string, error) {
+	tagData := make(map[string]interface{})
+	if err := c.Bind(&tagData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return nil, nil, swapErr.ErrBadData
+	}
+	tags := []string{}
+	tagsData, _ := tagData["tags"].([]interface{})
+	for _, tag := range tagsData {
+		if name, ok := tag.(string); ok {
+			tags = append(tags, name)
+		}
+	}
+
+	ids := []uint{}
+	if segmentId, ok := tagData["segment_id"].(float64); ok {
+		segment := &models.StudentSegment{ID: uint(segmentId)}
+		if err := segment.Find(); err != nil {
+			fmt.Println("ss.Find(bulkTagParams)", err)
+			return nil, nil, swapErr.ErrBadData
+		}
+		segmentIds, err := segment.StudentIds()
+		if err != nil {
+			fmt.Println("ss.StudentIds(bulkTagParams)", err)
+			return nil, nil, swapErr.ErrBadData
+		}
+		ids = segmentIds
+	} else {
+		idsData, _ := tagData["student_ids"].([]interface{})
+		for _, id := range idsData {
+			if id, ok := id.(float64); ok {
+				ids = append(ids, uint(id))
+			}
+		}
+		if len(ids) == 0 {
+			return nil, nil, swapErr.ErrNoStudents
+		}
+	}
+	return ids, tags, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"swapnil-ex/models"
 	"swapnil-ex/swapErr"
 
@@ -13,32 +14,46 @@ import (
 func GetTransactions(c echo.Context) error {
 	t := &models.Transaction{}
 	s := &models.Student{}
-	page := c.QueryParam("page")
-	newPage, err := strconv.Atoi(page)
+	lq, err := GetListQuery(c, models.TransactionListFields, "-id", 10)
 	if err != nil {
-		newPage = 1
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
 	}
 
 	search := c.QueryParam("search")
+	fuzzy, _ := strconv.ParseBool(c.QueryParam("fuzzy"))
 
-	err, ids := s.SearchIds(search)
+	fiscalYear, err := GetFiscalYearParam(c)
+	if err != nil {
+		fmt.Println("GetFiscalYearParam failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	err, ids := s.SearchIds(search, fuzzy)
 	if err != nil {
 		fmt.Println("s.ALL(SearchStudents)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
+	if strings.TrimSpace(search) != "" && len(ids) == 0 {
+		return c.JSON(http.StatusOK, map[string]interface{}{"transactions": []models.Transaction{}, "total": 0})
+	}
 
-	transactions, err := t.AllStudents(int(newPage), ids)
+	transactions, err := t.AllStudents(lq, ids, fiscalYear)
 	if err != nil {
 		fmt.Println("s.ALL(GetTransactions)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
-	count, err := t.Count(ids)
+	count, err := t.Count(lq, ids, fiscalYear)
 	if err != nil {
 		fmt.Println("s.ALL(GetTransactions)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, map[string]interface{}{"transactions": transactions, "total": count})
+	picked, err := lq.Pick(transactions)
+	if err != nil {
+		fmt.Println("lq.Pick(GetTransactions)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"transactions": picked, "total": count})
 }
 
 func GetStudentTransactions(c echo.Context) error {
@@ -56,13 +71,29 @@ func GetStudentTransactions(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
 
+	fiscalYear, err := GetFiscalYearParam(c)
+	if err != nil {
+		fmt.Println("GetFiscalYearParam failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	lq, err := GetListQuery(c, models.TransactionListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+
 	transaction := &models.Transaction{}
-	transactions, err := transaction.All(newStudentId)
+	transactions, err := transaction.All(lq, newStudentId, fiscalYear)
 	if err != nil {
 		fmt.Println("s.ALL(GetTransactions)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, transactions)
+	count, err := transaction.Count(lq, []uint{student.ID}, fiscalYear)
+	if err != nil {
+		fmt.Println("t.Count(GetStudentTransactions)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, transactions, count)
 }
 
 func GetStudentBalance(c echo.Context) error {
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetTrashSummaries(c echo.Context) error {
+	summaries, err := models.TrashSummaries()
+	if err != nil {
+		fmt.Println("models.TrashSummaries(GetTrashSummaries)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, summaries)
+}
+
+// GetTrash lists the deleted records of an entity, most recently deleted
+// first, 20 per page by default.
+func GetTrash(c echo.Context) error {
+	entity := c.Param("entity")
+	if !models.IsTrashEntity(entity) {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Unknown entity " + entity})
+	}
+	lq, err := GetListQuery(c, models.TrashListFields, "-deleted_at", 20)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	records, total, err := models.Trash(entity, lq)
+	if err != nil {
+		fmt.Println("models.Trash(GetTrash)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, records, total)
+}
+
+func RestoreTrash(c echo.Context) error {
+	cc := c.(CustomContext)
+	entity, id, reason, err := getTrashParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	restored, err := models.RestoreTrash(entity, id, reason, cc.session.UserID)
+	if err == swapErr.ErrNotInTrash {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	}
+	if errors.Is(err, swapErr.ErrTrashDependency) || err == swapErr.ErrStudentMerged || err == swapErr.ErrAlreadyHasClass ||
+		err == swapErr.ErrAlreadyInRoom || err == swapErr.ErrRoomFull || err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.RestoreTrash(RestoreTrash)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Restored", "restored": restored})
+}
+
+func PurgeTrash(c echo.Context) error {
+	cc := c.(CustomContext)
+	entity, id, reason, err := getTrashParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+	purged, err := models.PurgeTrash(entity, id, reason, cc.session.UserID)
+	if err == swapErr.ErrNotInTrash {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": err.Error()})
+	}
+	if errors.Is(err, swapErr.ErrTrashDependency) || err == swapErr.ErrTrashRetention || err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("models.PurgeTrash(PurgeTrash)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Purged", "purged": purged})
+}
+
+// PurgeExpiredTrash purges every record of an entity past the retention
+// period and reports the ones it had to leave.
+func PurgeExpiredTrash(c echo.Context) error {
+	cc := c.(CustomContext)
+	entity := c.Param("entity")
+	if !models.IsTrashEntity(entity) {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "Unknown entity " + entity})
+	}
+	trashData := make(map[string]interface{})
+	if err := c.Bind(&trashData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	reason, _ := trashData["reason"].(string)
+
+	report, err := models.PurgeExpiredTrash(entity, reason, cc.session.UserID)
+	if err != nil {
+		fmt.Println("models.PurgeExpiredTrash(PurgeExpiredTrash)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func GetTrashLogs(c echo.Context) error {
+	lq, err := GetListQuery(c, models.TrashLogListFields, "-id", 20)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	tl := &models.TrashLog{}
+	logs, err := tl.All(lq)
+	if err != nil {
+		fmt.Println("tl.All(GetTrashLogs)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	total, err := tl.Count(lq)
+	if err != nil {
+		fmt.Println("tl.Count(GetTrashLogs)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return ListJSON(c, lq, logs, total)
+}
+
+// getTrashParams reads the entity, the record id and the optional reason of a
+// restore or purge.
+func getTrashParams(c echo.Context) (string, uint, string, error) {
+	entity := c.Param("entity")
+	if !models.IsTrashEntity(entity) {
+		return "", 0, "", errors.New("Unknown entity " + entity)
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return "", 0, "", swapErr.ErrBadData
+	}
+	trashData := make(map[string]interface{})
+	if err := c.Bind(&trashData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return "", 0, "", swapErr.ErrBadData
+	}
+	reason, _ := trashData["reason"].(string)
+	return entity, uint(id), reason, nil
+}
@@ -17,12 +17,21 @@ import (
 
 func GetUsers(c echo.Context) error { 
 	u := &models.User{}
-	users, err := u.All()
+	lq, err := GetListQuery(c, models.UserListFields, "", 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	users, err := u.All(lq)
+	if err != nil {
+		fmt.Println("s.ALL(GetUsers)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	count, err := u.Count(lq)
 	if err != nil {
 		fmt.Println("s.ALL(GetUsers)", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
 	}
-	return c.JSON(http.StatusOK, users)	
+	return ListJSON(c, lq, users, count)
 } 
 
 func Register(c echo.Context) error {
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swapnil-ex/models"
+	"swapnil-ex/swapErr"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetStudentWriteOffs(c echo.Context) error {
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	w := &models.WriteOff{}
+	writeOffs, err := w.All(uint(newStudentId))
+	if err != nil {
+		fmt.Println("w.All(GetStudentWriteOffs)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, writeOffs)
+}
+
+func CreateStudentWriteOff(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId := c.Param("student_id")
+	newStudentId, err := strconv.Atoi(studentId)
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	student := &models.Student{ID: uint(newStudentId)}
+	err = student.Find()
+	if err != nil {
+		fmt.Println("s.Find(GetStudent)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+	if err := student.SaveBalance(); err != nil {
+		fmt.Println("s.SaveBalance(CreateStudentWriteOff)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	writeOffData := make(map[string]interface{})
+	if err := c.Bind(&writeOffData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+
+	writeOff := models.NewWriteOff(writeOffData, student)
+	writeOff.UserID = cc.session.UserID
+	if err := writeOff.Validate(); err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+
+	err = writeOff.Create()
+	if err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Println("w.Create(CreateStudentWriteOff)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": swapErr.ErrInternalServer.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Dues written off", "write_off": writeOff})
+}
+
+func ReverseStudentWriteOff(c echo.Context) error {
+	cc := c.(CustomContext)
+	studentId, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		fmt.Println("strconv.Atoi failed", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+	writeOff := &models.WriteOff{ID: uint(id)}
+	if err := writeOff.Find(); err != nil || writeOff.StudentId != uint(studentId) {
+		fmt.Println("w.Find(ReverseStudentWriteOff)", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": swapErr.ErrBadData.Error()})
+	}
+
+	reversalData := make(map[string]interface{})
+	if err := c.Bind(&reversalData); err != nil {
+		fmt.Println("c.Bind()", err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": swapErr.ErrBadData.Error()})
+	}
+	reason, _ := reversalData["reason"].(string)
+
+	err = writeOff.Reverse(reason, cc.session.UserID)
+	if err == swapErr.ErrWriteOffReversed || err == swapErr.ErrFiscalYearClosed {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+	}
+	if err != nil {
+		formErr := MarshalFormError(err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": formErr})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Write-off reversed", "write_off": writeOff})
+}
+
+func GetWriteOffsReport(c echo.Context) error {
+	summaries, err := models.WriteOffReport()
+	if err != nil {
+		fmt.Println("models.WriteOffReport(GetWriteOffsReport)", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": swapErr.ErrInternalServer.Error()})
+	}
+
+	total := 0.0
+	for _, summary := range summaries {
+		total = total + summary.Outstanding
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"write_offs": summaries, "total": total})
+}
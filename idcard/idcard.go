@@ -0,0 +1,186 @@
+// Package idcard draws student ID cards, one card as PDF or PNG or a stack
+// of cards on A4 sheets, from a layout Template.
+package idcard
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Card is what gets printed on one ID card. Photo is a JPEG or PNG and QR is
+// the content of the QR code.
+type Card struct {
+	Title 						string
+	StudentId 				uint
+	Name 							string
+	Class 						string
+	RollNumber 				string
+	Hostel 						string
+	BloodGroup 				string
+	EmergencyContact 	string
+	Photo 						[]byte
+	QR 								string
+}
+
+// Box is a rectangle on the card, in millimetres from the top left corner.
+type Box struct {
+	X, Y, W, H float64
+}
+
+// Template lays out a card. Sizes are in millimetres and font sizes in points.
+type Template struct {
+	Width, Height 	float64
+	Header 					Box
+	HeaderColor 		color.RGBA
+	TitleSize 			float64
+	Photo 					Box
+	QR 							Box
+	TextX 					float64
+	NameY 					float64
+	NameSize 				float64
+	FieldY 					float64
+	FieldHeight 		float64
+	FieldSize 			float64
+	FieldWidth 			float64
+	BorderColor 		color.RGBA
+	TextColor 			color.RGBA
+	MutedColor 			color.RGBA
+}
+
+// DefaultTemplate is a landscape CR80 card, the size of a bank card.
+var DefaultTemplate = Template{
+	Width: 85.6, Height: 54,
+	Header: Box{0, 0, 85.6, 10}, HeaderColor: color.RGBA{31, 58, 106, 255}, TitleSize: 10,
+	Photo: Box{4, 13, 20, 25}, QR: Box{63, 29, 19, 19},
+	TextX: 27, NameY: 17, NameSize: 9.5,
+	FieldY: 22.5, FieldHeight: 4.2, FieldSize: 6.5, FieldWidth: 35,
+	BorderColor: color.RGBA{190, 190, 190, 255}, TextColor: color.RGBA{20, 20, 20, 255}, MutedColor: color.RGBA{110, 110, 110, 255},
+}
+
+// canvas is where a card is drawn, in millimetres. Text is placed by its
+// baseline.
+type canvas interface {
+	Fill(b Box, c color.RGBA)
+	Outline(b Box, c color.RGBA)
+	Text(x, y float64, size float64, bold bool, c color.RGBA, text string)
+	Measure(text string, size float64, bold bool) float64
+	Image(b Box, img image.Image) error
+}
+
+type field struct {
+	label string
+	value string
+}
+
+// draw lays out card on c with its top left corner at x, y.
+func (t Template) draw(c canvas, x, y float64, card Card) error {
+	at := func(b Box) Box {
+		return Box{x + b.X, y + b.Y, b.W, b.H}
+	}
+	white := color.RGBA{255, 255, 255, 255}
+
+	c.Fill(at(Box{0, 0, t.Width, t.Height}), white)
+	c.Fill(at(t.Header), t.HeaderColor)
+	title := fit(c, card.Title, t.TitleSize, true, t.Header.W - 6)
+	c.Text(x + t.Header.X + (t.Header.W - c.Measure(title, t.TitleSize, true)) / 2, y + t.Header.Y + t.Header.H / 2 + t.TitleSize * 0.12,
+		t.TitleSize, true, white, title)
+
+	photo := at(t.Photo)
+	if img := decode(card.Photo); img != nil {
+		if err := c.Image(photo, crop(img, t.Photo.W / t.Photo.H)); err != nil {
+			return err
+		}
+	} else {
+		c.Text(photo.X + (photo.W - c.Measure("PHOTO", t.FieldSize, false)) / 2, photo.Y + photo.H / 2, t.FieldSize, false, t.MutedColor, "PHOTO")
+	}
+	c.Outline(photo, t.BorderColor)
+	id := "ID " + strconv.FormatUint(uint64(card.StudentId), 10)
+	c.Text(photo.X + (photo.W - c.Measure(id, t.FieldSize, true)) / 2, photo.Y + photo.H + 3.5, t.FieldSize, true, t.TextColor, id)
+
+	c.Text(x + t.TextX, y + t.NameY, t.NameSize, true, t.TextColor, fit(c, card.Name, t.NameSize, true, t.Width - t.TextX - 3))
+	fields := []field{{"Class", card.Class}, {"Roll No.", card.RollNumber}, {"Hostel", card.Hostel},
+		{"Blood Group", card.BloodGroup}, {"Emergency", card.EmergencyContact}}
+	row := 0
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		fieldY := y + t.FieldY + float64(row) * t.FieldHeight
+		label := f.label + ": "
+		labelWidth := c.Measure(label, t.FieldSize, false)
+		c.Text(x + t.TextX, fieldY, t.FieldSize, false, t.MutedColor, label)
+		c.Text(x + t.TextX + labelWidth, fieldY, t.FieldSize, true, t.TextColor, fit(c, f.value, t.FieldSize, true, t.FieldWidth - labelWidth))
+		row = row + 1
+	}
+
+	if card.QR != "" {
+		qr, err := qrcode.New(card.QR, qrcode.Medium)
+		if err != nil {
+			return err
+		}
+		qr.DisableBorder = true
+		bitmap := qr.Bitmap()
+		box := at(t.QR)
+		module := box.W / float64(len(bitmap))
+		black := color.RGBA{0, 0, 0, 255}
+		for i, line := range bitmap {
+			for j, dark := range line {
+				if dark {
+					c.Fill(Box{box.X + float64(j) * module, box.Y + float64(i) * module, module, module}, black)
+				}
+			}
+		}
+	}
+
+	c.Outline(at(Box{0, 0, t.Width, t.Height}), t.BorderColor)
+	return nil
+}
+
+// fit cuts text short with an ellipsis so it is no wider than width.
+func fit(c canvas, text string, size float64, bold bool, width float64) string {
+	if c.Measure(text, size, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes) - 1]
+		if short := string(runes) + "…"; c.Measure(short, size, bold) <= width {
+			return short
+		}
+	}
+	return ""
+}
+
+func decode(photo []byte) image.Image {
+	if len(photo) == 0 {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(photo))
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// crop trims img around its centre to the aspect ratio of the photo box.
+func crop(img image.Image, aspect float64) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if float64(w) / float64(h) > aspect {
+		w = int(float64(h) * aspect)
+	} else {
+		h = int(float64(w) / aspect)
+	}
+	x := bounds.Min.X + (bounds.Dx() - w) / 2
+	y := bounds.Min.Y + (bounds.Dy() - h) / 2
+	cropped := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(cropped, cropped.Bounds(), img, image.Point{x, y}, draw.Src)
+	return cropped
+}
@@ -0,0 +1,113 @@
+package idcard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// A4 sheets hold two columns of five cards.
+const (
+	sheetWidth = 210.0
+	sheetHeight = 297.0
+	sheetColumns = 2
+	sheetRows = 5
+	sheetGap = 4.0
+)
+
+type pdfCanvas struct {
+	pdf 		*gofpdf.Fpdf
+	images 	int
+}
+
+func newPDF(size gofpdf.SizeType) *pdfCanvas {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{UnitStr: "mm", Size: size})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddUTF8FontFromBytes("go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("go", "B", gobold.TTF)
+	pdf.SetLineWidth(0.2)
+	return &pdfCanvas{pdf: pdf}
+}
+
+// PDF writes a single card on a page of its own size.
+func (t Template) PDF(card Card, w io.Writer) error {
+	c := newPDF(gofpdf.SizeType{Wd: t.Width, Ht: t.Height})
+	c.pdf.AddPage()
+	if err := t.draw(c, 0, 0, card); err != nil {
+		return err
+	}
+	return c.pdf.Output(w)
+}
+
+// Sheets writes the cards on A4 pages ready to be cut out.
+func (t Template) Sheets(cards []Card, w io.Writer) error {
+	c := newPDF(gofpdf.SizeType{Wd: sheetWidth, Ht: sheetHeight})
+	marginX := (sheetWidth - sheetColumns * t.Width - (sheetColumns - 1) * sheetGap) / 2
+	marginY := (sheetHeight - sheetRows * t.Height - (sheetRows - 1) * sheetGap) / 2
+	if len(cards) == 0 {
+		c.pdf.AddPage()
+	}
+	for i, card := range cards {
+		slot := i % (sheetColumns * sheetRows)
+		if slot == 0 {
+			c.pdf.AddPage()
+		}
+		x := marginX + float64(slot % sheetColumns) * (t.Width + sheetGap)
+		y := marginY + float64(slot / sheetColumns) * (t.Height + sheetGap)
+		if err := t.draw(c, x, y, card); err != nil {
+			return err
+		}
+	}
+	return c.pdf.Output(w)
+}
+
+func (c *pdfCanvas) Fill(b Box, col color.RGBA) {
+	c.pdf.SetFillColor(int(col.R), int(col.G), int(col.B))
+	c.pdf.Rect(b.X, b.Y, b.W, b.H, "F")
+}
+
+func (c *pdfCanvas) Outline(b Box, col color.RGBA) {
+	c.pdf.SetDrawColor(int(col.R), int(col.G), int(col.B))
+	c.pdf.Rect(b.X, b.Y, b.W, b.H, "D")
+}
+
+func (c *pdfCanvas) font(size float64, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	c.pdf.SetFont("go", style, size)
+}
+
+func (c *pdfCanvas) Text(x, y float64, size float64, bold bool, col color.RGBA, text string) {
+	c.font(size, bold)
+	c.pdf.SetTextColor(int(col.R), int(col.G), int(col.B))
+	c.pdf.Text(x, y, text)
+}
+
+func (c *pdfCanvas) Measure(text string, size float64, bold bool) float64 {
+	c.font(size, bold)
+	return c.pdf.GetStringWidth(text)
+}
+
+// Image embeds img as a JPEG, whatever the photo was uploaded as.
+func (c *pdfCanvas) Image(b Box, img image.Image) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return err
+	}
+	c.images = c.images + 1
+	name := fmt.Sprintf("photo%d", c.images)
+	options := gofpdf.ImageOptions{ImageType: "JPG"}
+	c.pdf.RegisterImageOptionsReader(name, options, &buf)
+	c.pdf.ImageOptions(name, b.X, b.Y, b.W, b.H, false, options, 0, "")
+	return c.pdf.Error()
+}
@@ -0,0 +1,114 @@
+package idcard
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// pngDPI prints the PNG at the size of the card.
+const pngDPI = 300.0
+
+var regularFont, _ = opentype.Parse(goregular.TTF)
+var boldFont, _ = opentype.Parse(gobold.TTF)
+
+type pngCanvas struct {
+	img 	*image.RGBA
+	scale float64
+	faces map[float64]font.Face
+}
+
+// PNG writes a single card as a 300 dpi image.
+func (t Template) PNG(card Card, w io.Writer) error {
+	c := &pngCanvas{scale: pngDPI / 25.4, faces: map[float64]font.Face{}}
+	c.img = image.NewRGBA(image.Rect(0, 0, c.px(t.Width), c.px(t.Height)))
+	defer c.close()
+	if err := t.draw(c, 0, 0, card); err != nil {
+		return err
+	}
+	return png.Encode(w, c.img)
+}
+
+func (c *pngCanvas) px(mm float64) int {
+	return int(math.Round(mm * c.scale))
+}
+
+func (c *pngCanvas) rect(b Box) image.Rectangle {
+	return image.Rect(c.px(b.X), c.px(b.Y), c.px(b.X + b.W), c.px(b.Y + b.H))
+}
+
+func (c *pngCanvas) Fill(b Box, col color.RGBA) {
+	draw.Draw(c.img, c.rect(b), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+func (c *pngCanvas) Outline(b Box, col color.RGBA) {
+	r := c.rect(b)
+	line := c.px(0.2)
+	if line < 1 {
+		line = 1
+	}
+	src := image.NewUniform(col)
+	for _, side := range []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y + line), image.Rect(r.Min.X, r.Max.Y - line, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X + line, r.Max.Y), image.Rect(r.Max.X - line, r.Min.Y, r.Max.X, r.Max.Y)} {
+		draw.Draw(c.img, side, src, image.Point{}, draw.Src)
+	}
+}
+
+func (c *pngCanvas) face(size float64, bold bool) font.Face {
+	key := size
+	if bold {
+		key = -size
+	}
+	if face, ok := c.faces[key]; ok {
+		return face
+	}
+	f := regularFont
+	if bold {
+		f = boldFont
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: pngDPI, Hinting: font.HintingFull})
+	if err != nil {
+		return nil
+	}
+	c.faces[key] = face
+	return face
+}
+
+func (c *pngCanvas) close() {
+	for _, face := range c.faces {
+		face.Close()
+	}
+}
+
+func (c *pngCanvas) Text(x, y float64, size float64, bold bool, col color.RGBA, text string) {
+	face := c.face(size, bold)
+	if face == nil {
+		return
+	}
+	drawer := &font.Drawer{Dst: c.img, Src: image.NewUniform(col), Face: face,
+		Dot: fixed.P(c.px(x), c.px(y))}
+	drawer.DrawString(text)
+}
+
+func (c *pngCanvas) Measure(text string, size float64, bold bool) float64 {
+	face := c.face(size, bold)
+	if face == nil {
+		return 0
+	}
+	return float64(font.MeasureString(face, text).Ceil()) / c.scale
+}
+
+func (c *pngCanvas) Image(b Box, img image.Image) error {
+	draw.CatmullRom.Scale(c.img, c.rect(b), img, img.Bounds(), draw.Src, nil)
+	return nil
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"swapnil-ex/models/db"
+	"time"
+)
+
+var verhoeffMultiplication = [10][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+	{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+	{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+	{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+	{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+	{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+	{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+	{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+	{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+}
+
+var verhoeffPermutation = [8][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+	{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+	{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+	{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+	{9, 4, 5, 3, 1, 2, 7, 6, 8, 0},
+	{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+	{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+	{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+}
+
+type AadhaarAccessLog struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" gorm:"index"`
+	UserID					int `json:"user_id"`
+	IpAddress 			string `json:"ip_address"`
+	CreatedAt 			time.Time
+}
+
+func migrateAadhaarAccessLog() {
+	fmt.Println("migrating aadhaar access log..")
+	err := db.Driver.AutoMigrate(&AadhaarAccessLog{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// ValidAadhaar reports whether number is 12 digits, not starting with 0 or 1,
+// whose last digit is the Verhoeff check digit of the others.
+func ValidAadhaar(number string) bool {
+	if len(number) != 12 || number[0] < '2' {
+		return false
+	}
+	check := 0
+	for i := range number {
+		digit := number[len(number) - 1 - i]
+		if digit < '0' || digit > '9' {
+			return false
+		}
+		check = verhoeffMultiplication[check][verhoeffPermutation[i % 8][digit - '0']]
+	}
+	return check == 0
+}
+
+// aadhaarChanged reports whether the Aadhaar number differs from the saved
+// one. Numbers saved before the check digit was checked are kept as they are
+// until they are edited.
+func (s *Student) aadhaarChanged() (bool, error) {
+	if s.ID == 0 {
+		return true, nil
+	}
+	var saved []string
+	err := db.Driver.Model(&Student{}).Where("id = ?", s.ID).Pluck("adhar_card", &saved).Error
+	if err != nil || len(saved) == 0 {
+		return true, err
+	}
+	return saved[0] != s.AdharCard, nil
+}
+
+// MaskAadhaar keeps only the last four digits, XXXX-XXXX-1234.
+func MaskAadhaar(number string) string {
+	if len(number) < 4 {
+		return number
+	}
+	return "XXXX-XXXX-" + number[len(number) - 4:]
+}
+
+// MarshalJSON masks the Aadhaar number wherever a student is serialised. The
+// full number is only given out by RevealAadhaar.
+func (s Student) MarshalJSON() ([]byte, error) {
+	type student Student
+	return json.Marshal(struct {
+		student
+		AdharCard string `json:"adhar_card"`
+	}{student(s), MaskAadhaar(s.AdharCard)})
+}
+
+// RevealAadhaar returns the full Aadhaar number and logs who asked for it.
+func (s *Student) RevealAadhaar(userId int, ipAddress string) (string, error) {
+	accessLog := &AadhaarAccessLog{StudentId: s.ID, UserID: userId, IpAddress: ipAddress}
+	if err := db.Driver.Create(accessLog).Error; err != nil {
+		return "", err
+	}
+	return s.AdharCard, nil
+}
+
+func (s *Student) GetAadhaarAccessLogs() ([]AadhaarAccessLog, error) {
+	var accessLogs []AadhaarAccessLog
+	err := db.Driver.Where("student_id = ?", s.ID).Order("id desc").Find(&accessLogs).Error
+	return accessLogs, err
+}
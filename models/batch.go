@@ -51,12 +51,21 @@ func (b *Batch) Assign(batchData map[string]interface{}) {
 	}
 }
 
-func (b *Batch) All() ([]Batch, error) {
+var BatchListFields = ListFields{"id": "id", "name": "name", "year": "year", "standards_count": "standards_count",
+	"created_at": "created_at", "updated_at": "updated_at"}
+
+func (b *Batch) All(lq *ListQuery) ([]Batch, error) {
 	var batchs []Batch
-	err := db.Driver.Find(&batchs).Error
+	err := db.Driver.Scopes(lq.Scope).Find(&batchs).Error
 	return batchs, err
 }
 
+func (b *Batch) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&Batch{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
 func (b *Batch) Find() error {
 	err := db.Driver.First(b, "ID = ?", b.ID).Error
 	return err
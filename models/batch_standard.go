@@ -16,6 +16,8 @@ type BatchStandard struct {
 	Standard 				Standard
 	Fee							float64 `json:"fee"  validate:"nonzero"`
 	StudentsCount 	int64 `json:"students_count"`
+	RollNumberStrategy string `json:"roll_number_strategy" gorm:"default:'sequential'" validate:"regexp=^(sequential|alphabetical|custom)?$"`
+	RollNumberFormat string `json:"roll_number_format"`
 	CreatedAt 			time.Time
 	UpdatedAt 			time.Time
   DeletedAt 			gorm.DeletedAt `gorm:"index"`
@@ -54,14 +56,32 @@ func (bs *BatchStandard) Assign(batchStandardData map[string]interface{}) {
 	if fee, ok := batchStandardData["fee"]; ok {
 		bs.Fee = fee.(float64)
 	}
+
+	if rollNumberStrategy, ok := batchStandardData["roll_number_strategy"]; ok {
+		bs.RollNumberStrategy = rollNumberStrategy.(string)
+	}
+
+	if rollNumberFormat, ok := batchStandardData["roll_number_format"]; ok {
+		bs.RollNumberFormat = rollNumberFormat.(string)
+	}
 }
 
-func (bs *BatchStandard) All(batchId uint) ([]BatchStandard, error) {
+var BatchStandardListFields = ListFields{"id": "id", "batch_id": "batch_id", "standard_id": "standard_id", "fee": "fee",
+	"students_count": "students_count", "roll_number_strategy": "roll_number_strategy", "roll_number_format": "",
+	"standard": "", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (bs *BatchStandard) All(lq *ListQuery, batchId uint) ([]BatchStandard, error) {
 	var batchStandards []BatchStandard
-	err := db.Driver.Where("batch_id = ?", batchId).Preload("Standard").Find(&batchStandards).Error
+	err := db.Driver.Where("batch_id = ?", batchId).Preload("Standard").Scopes(lq.Scope).Find(&batchStandards).Error
 	return batchStandards, err
 }
 
+func (bs *BatchStandard) Count(lq *ListQuery, batchId uint) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&BatchStandard{}).Where("batch_id = ?", batchId).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
 func (bs *BatchStandard) AllIds(batchId uint) ([]uint, error) {
 	//var batchStandards []BatchStandard
 	var ids []uint
@@ -13,12 +13,14 @@ type BatchStandardStudent struct {
 	BatchId       		uint `json:"batch_id" validate:"nonzero"`
 	StandardId    		uint `json:"standard_id" validate:"nonzero"`
 	StudentId					uint `json:"student_id" validate:"nonzero"`
-	BatchStandardId 	uint `json:"batch_standard_id" validate:"nonzero"`
+	BatchStandardId 	uint `json:"batch_standard_id" validate:"nonzero" gorm:"uniqueIndex:idx_batch_standard_roll_number"`
 	Standard 					Standard
 	Batch 						Batch
 	BatchStandard     BatchStandard
 	Student 					Student
 	Fee 							float64 `json:"fee" validate:"nonzero"`
+	RollSequence 			int `json:"roll_sequence"`
+	RollNumber 				string `json:"roll_number" gorm:"uniqueIndex:idx_batch_standard_roll_number,where:roll_number <> '' and deleted_at is null"`
 	CreatedAt 				time.Time
 	UpdatedAt 				time.Time
   DeletedAt 				gorm.DeletedAt `gorm:"index"`
@@ -80,15 +82,17 @@ func (bs *BatchStandardStudent) Find() error {
 func (bss *BatchStandardStudent) Create() error {
 	err := db.Driver.Where(BatchStandardStudent{StudentId: bss.StudentId, BatchStandardId: bss.BatchStandardId}).
 	Assign(BatchStandardStudent{StandardId: bss.StandardId, BatchId: bss.BatchId}).FirstOrCreate(bss).Error
-	bss.updateCount()
+	bss.updateCount(db.Driver)
 	err = bss.AddTransaction()
 	return err
 }
 
-func (bs *BatchStandardStudent) updateCount() {
+func (bs *BatchStandardStudent) updateCount(tx *gorm.DB) error {
 	var count int64
-	db.Driver.Model(&BatchStandardStudent{}).Where("batch_standard_id = ?", bs.BatchStandardId).Count(&count)
-	db.Driver.Model(&BatchStandard{}).Where("id = ?", bs.BatchStandardId).Update("students_count", count)
+	if err := tx.Model(&BatchStandardStudent{}).Where("batch_standard_id = ?", bs.BatchStandardId).Count(&count).Error; err != nil {
+		return err
+	}
+	return tx.Model(&BatchStandard{}).Where("id = ?", bs.BatchStandardId).Update("students_count", count).Error
 }
 
 func (bs *BatchStandardStudent) Update() error {
@@ -98,16 +102,27 @@ func (bs *BatchStandardStudent) Update() error {
 
 func (bs *BatchStandardStudent) Delete() error {
 	err := db.Driver.Delete(bs).Error
+	if err == nil && bs.RollNumber != "" {
+		err = db.Driver.Model(&Student{}).Where("id = ? and roll_number = ?", bs.StudentId, bs.RollNumber).Update("roll_number", "").Error
+		if err == nil {
+			err = indexStudent(db.Driver, bs.StudentId)
+		}
+	}
 	return err
 }
 
 func (bss *BatchStandardStudent) AddTransaction() error{
-	transaction := &Transaction{}
 	batchStandard := &BatchStandard{ID: bss.BatchStandardId}
 	err := batchStandard.Find()
 	if err != nil {
 		return err
 	}
+	return bss.addTransaction(db.Driver, batchStandard)
+}
+
+// addTransaction bills the enrollment fee through tx.
+func (bss *BatchStandardStudent) addTransaction(tx *gorm.DB, batchStandard *BatchStandard) error {
+	transaction := &Transaction{}
 	transactionCategory, err := batchStandard.GetTransactionCategory()
 	if err != nil {
 		return err
@@ -115,10 +130,9 @@ func (bss *BatchStandardStudent) AddTransaction() error{
 
 	transactionData := map[string]interface{}{"name": "New Adminission", "student_id": float64(bss.StudentId), 
 		"transaction_category_id": float64(transactionCategory.ID), "batch_standard_student_id": float64(bss.ID), "is_cleared": true, "transaction_type": "debit", 
-		"amount": batchStandard.Fee}
+		"amount": bss.Fee}
 	transaction.Assign(transactionData)
-	err = transaction.Create()
-	return err
+	return transaction.create(tx)
 }
 
 func (bs *BatchStandardStudent) GetTransactions() ([]Transaction, error) {
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+)
+
+const (
+	TransferFeeProrated = "prorated"
+	TransferFeeFull 		= "full"
+)
+
+type BatchStandardTransfer struct {
+	ID            							uint `json:"id"`
+	StudentId										uint `json:"student_id" gorm:"index"`
+	FromBatchStandardStudentId 	uint `json:"from_batch_standard_student_id"`
+	FromBatchStandardId 				uint `json:"from_batch_standard_id"`
+	FromRollNumber 							string `json:"from_roll_number"`
+	FromFee 										float64 `json:"from_fee"`
+	ToBatchStandardStudentId 		uint `json:"to_batch_standard_student_id"`
+	ToBatchStandardId 					uint `json:"to_batch_standard_id"`
+	ToRollNumber 								string `json:"to_roll_number"`
+	ToFee 											float64 `json:"to_fee"`
+	FeeMode 										string `json:"fee_mode"`
+	EffectiveOn 								time.Time `json:"effective_on"`
+	Amount 											float64 `json:"amount"`
+	TransactionId 							uint `json:"transaction_id"`
+	Reason 											string `json:"reason"`
+	UserID											int `json:"user_id"`
+	FromBatchStandard 					BatchStandard `json:"from_batch_standard"`
+	ToBatchStandard 						BatchStandard `json:"to_batch_standard"`
+	CreatedAt 									time.Time
+	UpdatedAt 									time.Time
+  DeletedAt 									gorm.DeletedAt `gorm:"index"`
+}
+
+func migrateBatchStandardTransfer() {
+	fmt.Println("migrating batch standard transfer..")
+	err := db.Driver.AutoMigrate(&BatchStandardTransfer{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func (bst *BatchStandardTransfer) All(studentId uint) ([]BatchStandardTransfer, error) {
+	var transfers []BatchStandardTransfer
+	err := db.Driver.Preload("FromBatchStandard.Batch").Preload("FromBatchStandard.Standard").
+		Preload("ToBatchStandard.Batch").Preload("ToBatchStandard.Standard").
+		Where("student_id = ?", studentId).Order("effective_on desc, id desc").Find(&transfers).Error
+	return transfers, err
+}
+
+func (bst *BatchStandardTransfer) Find() error {
+	err := db.Driver.First(bst, "ID = ?", bst.ID).Error
+	return err
+}
+
+// TransferBatchStandard moves the student from their current class to
+// batchStandard from effectiveOn. The difference between the fee charged for
+// the old class and the fee of the new one is posted on the new enrollment,
+// in full or prorated over the rest of the academic year, as a debit when
+// the new class costs more and a credit when it costs less.
+func (s *Student) TransferBatchStandard(batchStandard *BatchStandard, feeMode string, effectiveOn time.Time, userId int, reason string) (*BatchStandardTransfer, error) {
+	var current BatchStandardStudent
+	err := db.Driver.Where("student_id = ?", s.ID).Order("id desc").First(&current).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, swapErr.ErrNoClassAssigned
+	}
+	if err != nil {
+		return nil, err
+	}
+	if current.BatchStandardId == batchStandard.ID {
+		return nil, swapErr.ErrAlreadyInClass
+	}
+	if InClosedFiscalYear(effectiveOn) {
+		return nil, swapErr.ErrFiscalYearClosed
+	}
+	category, err := batchStandard.GetTransactionCategory()
+	if err != nil {
+		return nil, err
+	}
+	fee, err := batchStandard.FeeOn(effectiveOn)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &BatchStandardTransfer{StudentId: s.ID, FromBatchStandardStudentId: current.ID, FromBatchStandardId: current.BatchStandardId,
+		FromRollNumber: current.RollNumber, FromFee: current.Fee, ToBatchStandardId: batchStandard.ID, ToFee: fee,
+		FeeMode: feeMode, EffectiveOn: startOfDay(effectiveOn), UserID: userId, Reason: reason}
+	difference := fee - current.Fee
+	if feeMode == TransferFeeProrated {
+		yearStart, yearEnd := HostelBillingPeriod("yearly", transfer.EffectiveOn)
+		difference, _ = prorate(difference, yearStart, yearEnd, transfer.EffectiveOn, yearEnd)
+	}
+	transfer.Amount = math.Round(difference * 100) / 100
+
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&BatchStandardStudent{}, current.ID).Error; err != nil {
+			return err
+		}
+		sequence, err := batchStandard.nextRollSequence(tx)
+		if err != nil {
+			return err
+		}
+		bss := &BatchStandardStudent{BatchId: batchStandard.BatchId, StandardId: batchStandard.StandardId, StudentId: s.ID,
+			BatchStandardId: batchStandard.ID, Fee: fee, RollSequence: sequence, RollNumber: batchStandard.FormatRollNumber(sequence)}
+		if err := tx.Omit("Standard", "Batch", "BatchStandard", "Student").Create(bss).Error; err != nil {
+			return err
+		}
+		transfer.ToBatchStandardStudentId = bss.ID
+		transfer.ToRollNumber = bss.RollNumber
+
+		if transfer.Amount != 0.0 {
+			transactionType, amount := "debit", transfer.Amount
+			if amount < 0.0 {
+				transactionType, amount = "credit", -amount
+			}
+			transaction := &Transaction{Name: "Class Transfer Adjustment", StudentId: s.ID, TransactionCategoryId: category.ID,
+				BatchStandardStudentId: bss.ID, IsCleared: true, PaidBy: "-", PaymentMode: "-", TransactionType: transactionType,
+				Amount: amount, Reason: reason}
+			if err := tx.Omit("Student").Create(transaction).Error; err != nil {
+				return err
+			}
+			transfer.TransactionId = transaction.ID
+		}
+
+		if err := tx.Model(&Student{}).Where("id = ?", s.ID).Update("roll_number", bss.RollNumber).Error; err != nil {
+			return err
+		}
+		if err := indexStudent(tx, s.ID); err != nil {
+			return err
+		}
+		return tx.Omit("FromBatchStandard", "ToBatchStandard").Create(transfer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	current.updateCount(db.Driver)
+	(&BatchStandardStudent{BatchStandardId: batchStandard.ID}).updateCount(db.Driver)
+	if err := s.Find(); err != nil {
+		return nil, err
+	}
+	return transfer, s.SaveBalance()
+}
@@ -0,0 +1,400 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"swapnil-ex/certificate"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+const (
+	CertificateLeaving = "leaving"
+	CertificateBonafide = "bonafide"
+	CertificateFeePaid = "fee_paid"
+
+	CertificateIssued = "Issued"
+	CertificateCancelled = "Cancelled"
+)
+
+// certificateSerialPrefixes start the serial numbers of each kind, as in
+// "LC/2026-27/0001".
+var certificateSerialPrefixes = map[string]string{CertificateLeaving: "LC", CertificateBonafide: "BC", CertificateFeePaid: "FC"}
+
+var certificatePlaceholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// CertificateTemplate is the editable text of a certificate. Title and Body
+// hold {{placeholders}} filled in from the student when it is issued.
+type CertificateTemplate struct {
+	ID            	uint `json:"id"`
+	Kind 						string `json:"kind" validate:"regexp=^(leaving|bonafide|fee_paid)$"`
+	Name 						string `json:"name" validate:"nonzero"`
+	Title 					string `json:"title" validate:"nonzero"`
+	Body 						string `json:"body" validate:"nonzero"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+// Certificate is an issued certificate in the register. The text is kept as
+// it was printed so a copy reads the same after the template or the student
+// changes.
+type Certificate struct {
+	ID            					uint `json:"id"`
+	SerialNumber 						string `json:"serial_number" gorm:"uniqueIndex"`
+	Year 										string `json:"year" gorm:"index:idx_certificate_serial"`
+	Sequence 								int `json:"sequence"`
+	Kind 										string `json:"kind" gorm:"index:idx_certificate_serial"`
+	CertificateTemplateId 	uint `json:"certificate_template_id"`
+	StudentId 							uint `json:"student_id" gorm:"index"`
+	Title 									string `json:"title"`
+	Body 										string `json:"body"`
+	Fields 									string `json:"fields"`
+	Status 									string `json:"status" gorm:"default:'Issued'"`
+	UserID									int `json:"user_id"`
+	CancelReason 						string `json:"cancel_reason"`
+	CancelledBy 						int `json:"cancelled_by"`
+	CancelledAt 						*time.Time `json:"cancelled_at"`
+	Student 								Student `json:"student"`
+	CreatedAt 							time.Time
+	UpdatedAt 							time.Time
+  DeletedAt 							gorm.DeletedAt `gorm:"index"`
+}
+
+var CertificateListFields = ListFields{"id": "id", "serial_number": "serial_number", "year": "year", "kind": "kind",
+	"certificate_template_id": "certificate_template_id", "student_id": "student_id", "status": "status", "user_id": "user_id",
+	"title": "", "body": "", "fields": "", "cancel_reason": "", "cancelled_by": "cancelled_by", "cancelled_at": "cancelled_at",
+	"student": "", "created_at": "created_at", "updated_at": "updated_at"}
+
+// defaultCertificateTemplates are created on the first migration so each
+// kind can be issued before anyone edits a template.
+var defaultCertificateTemplates = []CertificateTemplate{
+	{Kind: CertificateLeaving, Name: "Leaving Certificate", Title: "LEAVING CERTIFICATE",
+		Body: "This is to certify that {{name}}, son/daughter of {{parent_name}}, born on {{birth_date}} ({{birth_date_words}}), " +
+			"was a student of this school from {{admission_date}} to {{leaving_date}} and last studied in {{class}}.\n\n" +
+			"Reason for leaving: {{reason_for_leaving}}\nConduct: {{conduct}}\n\nAll dues to the school have been paid."},
+	{Kind: CertificateBonafide, Name: "Bonafide Certificate", Title: "BONAFIDE CERTIFICATE",
+		Body: "This is to certify that {{name}}, son/daughter of {{parent_name}}, is a bonafide student of this school, " +
+			"studying in {{class}} with roll number {{roll_number}} in the academic year {{academic_year}}. " +
+			"According to our records the date of birth is {{birth_date}}.\n\nThis certificate is issued for {{purpose}}."},
+	{Kind: CertificateFeePaid, Name: "Fee Paid Certificate", Title: "FEE PAID CERTIFICATE",
+		Body: "This is to certify that {{name}}, studying in {{class}}, has paid Rs. {{paid}} ({{paid_words}} rupees) " +
+			"towards fees of Rs. {{fee}} for the academic year {{academic_year}}. Outstanding dues: Rs. {{dues}}."},
+}
+
+func migrateCertificate() {
+	fmt.Println("migrating certificate..")
+	err := db.Driver.AutoMigrate(&CertificateTemplate{}, &Certificate{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	var count int64
+	db.Driver.Unscoped().Model(&CertificateTemplate{}).Count(&count)
+	if count == 0 {
+		templates := defaultCertificateTemplates
+		db.Driver.Create(&templates)
+	}
+}
+
+func NewCertificateTemplate(certificateTemplateData map[string]interface{}) *CertificateTemplate {
+	certificateTemplate := &CertificateTemplate{}
+	certificateTemplate.Assign(certificateTemplateData)
+	return certificateTemplate
+}
+
+func (ct *CertificateTemplate) Validate() error {
+	if errs := validator.Validate(ct); errs != nil {
+		return errs
+	} else {
+		return nil
+	}
+}
+
+func (ct *CertificateTemplate) Assign(certificateTemplateData map[string]interface{}) {
+	if kind, ok := certificateTemplateData["kind"]; ok {
+		ct.Kind, _ = kind.(string)
+	}
+	if name, ok := certificateTemplateData["name"]; ok {
+		ct.Name, _ = name.(string)
+	}
+	if title, ok := certificateTemplateData["title"]; ok {
+		ct.Title, _ = title.(string)
+	}
+	if body, ok := certificateTemplateData["body"]; ok {
+		ct.Body, _ = body.(string)
+	}
+}
+
+func (ct *CertificateTemplate) All(kind string) ([]CertificateTemplate, error) {
+	var certificateTemplates []CertificateTemplate
+	query := db.Driver.Order("kind, id")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := query.Find(&certificateTemplates).Error
+	return certificateTemplates, err
+}
+
+func (ct *CertificateTemplate) Find() error {
+	err := db.Driver.First(ct, "ID = ?", ct.ID).Error
+	return err
+}
+
+func (ct *CertificateTemplate) Create() error {
+	err := db.Driver.Create(ct).Error
+	return err
+}
+
+func (ct *CertificateTemplate) Update() error {
+	err := db.Driver.Save(ct).Error
+	return err
+}
+
+func (ct *CertificateTemplate) Delete() error {
+	err := db.Driver.Delete(ct).Error
+	return err
+}
+
+// Placeholders lists the names used in the title and body of the template.
+func (ct *CertificateTemplate) Placeholders() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, match := range certificatePlaceholder.FindAllStringSubmatch(ct.Title + "\n" + ct.Body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// CertificateValues are the placeholders filled in from the student, their
+// latest enrollment and their fees.
+func (s *Student) CertificateValues() (map[string]string, error) {
+	if err := s.SaveBalance(); err != nil {
+		return nil, err
+	}
+	fee, paid := s.GetBalance()
+	dues := 0.0
+	if s.Balance < 0.0 {
+		dues = -s.Balance
+	}
+	now := time.Now()
+	start, end := FiscalYearBounds(now)
+	values := map[string]string{
+		"school_name": os.Getenv("SCHOOL_NAME"),
+		"issue_date": now.Format("02-01-2006"),
+		"academic_year": fmt.Sprintf("%d-%02d", start.Year(), end.Year() % 100),
+		"student_id": fmt.Sprint(s.ID),
+		"name": strings.Join(strings.Fields(s.FirstName + " " + s.MiddleName + " " + s.LastName), " "),
+		"first_name": s.FirstName,
+		"middle_name": s.MiddleName,
+		"last_name": s.LastName,
+		"parent_name": s.ParentName,
+		"parent_occupation": s.ParentOccupation,
+		"birth_date": s.BirthDate.Format("02-01-2006"),
+		"birth_date_words": dateInWords(s.BirthDate),
+		"town": s.Town,
+		"contact_number": s.ContactNumber,
+		"status": s.Status,
+		"admission_date": s.CreatedAt.Format("02-01-2006"),
+		"roll_number": s.RollNumber,
+		"fee": fmt.Sprintf("%.2f", fee),
+		"paid": fmt.Sprintf("%.2f", paid),
+		"paid_words": Convert(int(paid)),
+		"dues": fmt.Sprintf("%.2f", dues),
+	}
+
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Unscoped().Preload("BatchStandard.Batch").Preload("BatchStandard.Standard").Where("student_id = ?", s.ID).
+		Order("id desc").Limit(1).Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(batchStandardStudents) > 0 {
+		bss := batchStandardStudents[0]
+		values["class"] = batchStandardName(bss.BatchStandard)
+		values["standard"] = bss.BatchStandard.Standard.Name
+		values["batch"] = bss.BatchStandard.Batch.Name
+		if bss.RollNumber != "" {
+			values["roll_number"] = bss.RollNumber
+		}
+		if bss.DeletedAt.Valid {
+			values["leaving_date"] = bss.DeletedAt.Time.Format("02-01-2006")
+		}
+	}
+	return values, nil
+}
+
+// checkCertificate applies the rules of each kind before a certificate is
+// issued: a leaving certificate needs the dues cleared and is issued once,
+// a bonafide certificate needs a student still in a class and a fee paid
+// certificate needs some fees paid.
+func (s *Student) checkCertificate(kind string) error {
+	switch kind {
+	case CertificateLeaving:
+		if err := s.SaveBalance(); err != nil {
+			return err
+		}
+		if s.Balance < 0.0 {
+			return swapErr.ErrBalanceNotCleared
+		}
+		var issued int64
+		err := db.Driver.Model(&Certificate{}).Where("student_id = ? and kind = ? and status = ?", s.ID, CertificateLeaving, CertificateIssued).
+			Count(&issued).Error
+		if err != nil {
+			return err
+		}
+		if issued > 0 {
+			return swapErr.ErrCertificateIssued
+		}
+	case CertificateBonafide:
+		if s.Status == StudentDropped || s.Status == StudentPassedOut || s.Status == StudentTransferred {
+			return swapErr.ErrStudentLeft
+		}
+		if len(s.GetBatchStandardStudents()) == 0 {
+			return swapErr.ErrNoClassAssigned
+		}
+	case CertificateFeePaid:
+		_, paid := s.GetBalance()
+		if paid <= 0.0 {
+			return swapErr.ErrNoFeesPaid
+		}
+	}
+	return nil
+}
+
+// RenderCertificate fills in the template for the student without issuing
+// it. fields fill the placeholders the student's records do not, such as
+// reason_for_leaving or conduct. The serial number is left as a
+// placeholder until the certificate is issued.
+func (s *Student) RenderCertificate(ct *CertificateTemplate, fields map[string]string) (*Certificate, error) {
+	if err := s.checkCertificate(ct.Kind); err != nil {
+		return nil, err
+	}
+	values, err := s.CertificateValues()
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range fields {
+		if _, ok := values[name]; !ok || values[name] == "" {
+			values[name] = value
+		}
+	}
+	missing := []string{}
+	for _, name := range ct.Placeholders() {
+		if _, ok := values[name]; !ok && name != "serial_number" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: %s", swapErr.ErrCertificateFields, strings.Join(missing, ", "))
+	}
+
+	fill := func(text string) string {
+		return certificatePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := certificatePlaceholder.FindStringSubmatch(placeholder)[1]
+			if value, ok := values[name]; ok {
+				return value
+			}
+			return placeholder
+		})
+	}
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{Kind: ct.Kind, CertificateTemplateId: ct.ID, StudentId: s.ID, Title: fill(ct.Title), Body: fill(ct.Body),
+		Fields: string(fieldsJSON), Status: CertificateIssued, Student: *s}, nil
+}
+
+// IssueCertificate renders the template for the student and enters it in
+// the register under the next serial number of its kind for the year.
+func (s *Student) IssueCertificate(ct *CertificateTemplate, fields map[string]string, userId int) (*Certificate, error) {
+	certificate, err := s.RenderCertificate(ct, fields)
+	if err != nil {
+		return nil, err
+	}
+	certificate.UserID = userId
+	start, end := FiscalYearBounds(time.Now())
+	certificate.Year = fmt.Sprintf("%d-%02d", start.Year(), end.Year() % 100)
+
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		var sequence int
+		err := tx.Unscoped().Model(&Certificate{}).Where("kind = ? and year = ?", certificate.Kind, certificate.Year).
+			Select("coalesce(max(sequence), 0)").Scan(&sequence).Error
+		if err != nil {
+			return err
+		}
+		certificate.Sequence = sequence + 1
+		certificate.SerialNumber = fmt.Sprintf("%s/%s/%04d", certificateSerialPrefixes[certificate.Kind], certificate.Year, certificate.Sequence)
+		certificate.Title = strings.ReplaceAll(certificate.Title, "{{serial_number}}", certificate.SerialNumber)
+		certificate.Body = certificatePlaceholder.ReplaceAllStringFunc(certificate.Body, func(placeholder string) string {
+			if certificatePlaceholder.FindStringSubmatch(placeholder)[1] == "serial_number" {
+				return certificate.SerialNumber
+			}
+			return placeholder
+		})
+		return tx.Omit("Student").Create(certificate).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+func (c *Certificate) All(lq *ListQuery) ([]Certificate, error) {
+	var certificates []Certificate
+	err := db.Driver.Preload("Student").Scopes(lq.Scope).Find(&certificates).Error
+	return certificates, err
+}
+
+func (c *Certificate) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&Certificate{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
+func (c *Certificate) Find() error {
+	err := db.Driver.Preload("Student").First(c, "ID = ?", c.ID).Error
+	return err
+}
+
+// Cancel keeps the certificate in the register as cancelled, so its serial
+// number is not reused and another one of its kind can be issued.
+func (c *Certificate) Cancel(reason string, userId int) error {
+	if c.Status == CertificateCancelled {
+		return swapErr.ErrCertificateCancelled
+	}
+	now := time.Now()
+	c.Status, c.CancelReason, c.CancelledBy, c.CancelledAt = CertificateCancelled, reason, userId, &now
+	return db.Driver.Model(c).Omit("Student").Updates(map[string]interface{}{"status": c.Status, "cancel_reason": reason,
+		"cancelled_by": userId, "cancelled_at": now}).Error
+}
+
+// Document is the certificate as it is printed, marked when cancelled.
+func (c *Certificate) Document() certificate.Document {
+	return certificate.Document{School: os.Getenv("SCHOOL_NAME"), Title: c.Title, SerialNumber: c.SerialNumber,
+		Date: c.CreatedAt.Format("02-01-2006"), Body: c.Body, Cancelled: c.Status == CertificateCancelled}
+}
+
+// dateInWords spells out a date the way leaving certificates print the date
+// of birth, as in "Fifteen March Two Thousand Ten".
+func dateInWords(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	words := strings.Fields(Convert(date.Day()) + " " + date.Month().String() + " " + Convert(date.Year()))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,266 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+const (
+	CustomFieldText = "text"
+	CustomFieldNumber = "number"
+	CustomFieldDate = "date"
+	CustomFieldBoolean = "boolean"
+	CustomFieldSelect = "select"
+)
+
+// CustomField is an extra student field defined by an admin. Values are kept
+// in Student.CustomFields under Key, in the type of the field; Pattern is a
+// regular expression the text of a value has to match.
+type CustomField struct {
+	ID            	uint `json:"id"`
+	Key 						string `json:"key" validate:"regexp=^[a-z][a-z0-9_]*$" gorm:"column:field_key;index"`
+	Label 					string `json:"label" validate:"nonzero"`
+	FieldType 			string `json:"field_type" validate:"regexp=^(text|number|date|boolean|select)$"`
+	Required 				bool `json:"required" gorm:"default:false"`
+	Options 				[]string `json:"options" gorm:"serializer:json"`
+	Pattern 				string `json:"pattern"`
+	Position 				int `json:"position" gorm:"default:0"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+func migrateCustomField() {
+	fmt.Println("migrating custom field..")
+	err := db.Driver.AutoMigrate(&CustomField{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func NewCustomField(customFieldData map[string]interface{}) *CustomField {
+	customField := &CustomField{}
+	customField.Assign(customFieldData)
+	return customField
+}
+
+// Validate checks the definition and that its key is free: not used by
+// another custom field nor by a student field.
+func (cf *CustomField) Validate() error {
+	errs := validator.ErrorMap{}
+	if err := validator.Validate(cf); err != nil {
+		errs = err.(validator.ErrorMap)
+	}
+	if cf.FieldType == CustomFieldSelect && len(cf.Options) == 0 {
+		errs["Options"] = append(errs["Options"], validator.TextErr{Err: swapErr.ErrCustomFieldOptions})
+	}
+	if cf.Pattern != "" {
+		if _, err := regexp.Compile(cf.Pattern); err != nil {
+			errs["Pattern"] = append(errs["Pattern"], validator.ErrRegexp)
+		}
+	}
+	if _, ok := StudentListFields[cf.Key]; ok || studentExportColumn(cf.Key) {
+		errs["Key"] = append(errs["Key"], validator.TextErr{Err: swapErr.ErrCustomFieldKeyTaken})
+	} else {
+		var taken int64
+		if err := db.Driver.Model(&CustomField{}).Where("field_key = ? and id <> ?", cf.Key, cf.ID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			errs["Key"] = append(errs["Key"], validator.TextErr{Err: swapErr.ErrCustomFieldKeyTaken})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Assign sets the definition from the request. The key is only set on a
+// new field, since students keep their values under it.
+func (cf *CustomField) Assign(customFieldData map[string]interface{}) {
+	if key, ok := customFieldData["key"]; ok && cf.ID == 0 {
+		cf.Key, _ = key.(string)
+	}
+	if label, ok := customFieldData["label"]; ok {
+		cf.Label, _ = label.(string)
+	}
+	if fieldType, ok := customFieldData["field_type"]; ok {
+		cf.FieldType, _ = fieldType.(string)
+	}
+	if required, ok := customFieldData["required"]; ok {
+		cf.Required, _ = required.(bool)
+	}
+	if options, ok := customFieldData["options"].([]interface{}); ok {
+		cf.Options = []string{}
+		for _, option := range options {
+			if option, ok := option.(string); ok && strings.TrimSpace(option) != "" {
+				cf.Options = append(cf.Options, strings.TrimSpace(option))
+			}
+		}
+	}
+	if pattern, ok := customFieldData["pattern"]; ok {
+		cf.Pattern, _ = pattern.(string)
+	}
+	if position, ok := customFieldData["position"].(float64); ok {
+		cf.Position = int(position)
+	}
+}
+
+func (cf *CustomField) All() ([]CustomField, error) {
+	var customFields []CustomField
+	err := db.Driver.Order("position, id").Find(&customFields).Error
+	return customFields, err
+}
+
+func (cf *CustomField) Find() error {
+	err := db.Driver.First(cf, "ID = ?", cf.ID).Error
+	return err
+}
+
+func (cf *CustomField) Create() error {
+	err := db.Driver.Create(cf).Error
+	return err
+}
+
+func (cf *CustomField) Update() error {
+	err := db.Driver.Save(cf).Error
+	return err
+}
+
+// Delete removes the definition and the values students had for it.
+func (cf *CustomField) Delete() error {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(cf).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&Student{}).Where(cf.column() + " is not null").
+			Update("custom_fields", gorm.Expr("json_remove(custom_fields, ?)", cf.path())).Error
+	})
+}
+
+func (cf *CustomField) path() string {
+	return "$." + cf.Key
+}
+
+// column reads the value of the field from the students table. Numbers are
+// cast so they compare as numbers.
+func (cf *CustomField) column() string {
+	column := fmt.Sprintf("json_extract(custom_fields, '%s')", cf.path())
+	if cf.FieldType == CustomFieldNumber {
+		return "CAST(" + column + " AS REAL)"
+	}
+	return column
+}
+
+// normalize converts value to the type of the field, accepting the text
+// form of numbers, dates and booleans as sent by forms and imports.
+func (cf *CustomField) normalize(value interface{}) (interface{}, error) {
+	text := strings.TrimSpace(fmt.Sprint(value))
+	var normalized interface{} = text
+	switch cf.FieldType {
+	case CustomFieldNumber:
+		number, ok := value.(float64)
+		if !ok {
+			parsed, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, validator.ErrInvalid
+			}
+			number = parsed
+		}
+		normalized = number
+	case CustomFieldBoolean:
+		boolean, ok := value.(bool)
+		if !ok {
+			parsed, err := strconv.ParseBool(text)
+			if err != nil {
+				return nil, validator.ErrInvalid
+			}
+			boolean = parsed
+		}
+		normalized = boolean
+	case CustomFieldDate:
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return nil, validator.ErrInvalid
+		}
+	case CustomFieldSelect:
+		found := false
+		for _, option := range cf.Options {
+			if option == text {
+				found = true
+			}
+		}
+		if !found {
+			return nil, validator.ErrInvalid
+		}
+	}
+	if cf.Pattern != "" {
+		if matched, _ := regexp.MatchString(cf.Pattern, text); !matched {
+			return nil, validator.ErrRegexp
+		}
+	}
+	return normalized, nil
+}
+
+// validateCustomFields checks the custom field values of the student against
+// their definitions and stores each in the type of its field. Empty values
+// are dropped, which fails when the field is required.
+func (s *Student) validateCustomFields() (validator.ErrorMap, error) {
+	customFields, err := (&CustomField{}).All()
+	if err != nil {
+		return nil, err
+	}
+	errs := validator.ErrorMap{}
+	defined := map[string]bool{}
+	for _, customField := range customFields {
+		defined[customField.Key] = true
+		name := "CustomFields." + customField.Key
+		value, ok := s.CustomFields[customField.Key]
+		if ok && (value == nil || strings.TrimSpace(fmt.Sprint(value)) == "") {
+			delete(s.CustomFields, customField.Key)
+			ok = false
+		}
+		if !ok {
+			if customField.Required {
+				errs[name] = append(errs[name], validator.ErrZeroValue)
+			}
+			continue
+		}
+		normalized, err := customField.normalize(value)
+		if err != nil {
+			errs[name] = append(errs[name], err)
+			continue
+		}
+		s.CustomFields[customField.Key] = normalized
+	}
+	for key := range s.CustomFields {
+		if !defined[key] {
+			errs["CustomFields." + key] = append(errs["CustomFields." + key], validator.TextErr{Err: swapErr.ErrCustomFieldUnknown})
+		}
+	}
+	return errs, nil
+}
+
+// StudentListFieldsWithCustom adds the custom fields to StudentListFields so
+// students can be filtered and sorted by them.
+func StudentListFieldsWithCustom() (ListFields, error) {
+	customFields, err := (&CustomField{}).All()
+	if err != nil {
+		return nil, err
+	}
+	fields := ListFields{}
+	for name, column := range StudentListFields {
+		fields[name] = column
+	}
+	for _, customField := range customFields {
+		fields[customField.Key] = customField.column()
+	}
+	return fields, nil
+}
@@ -0,0 +1,269 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+// Deposit is a refundable security deposit. It is a liability held for the
+// student and only reaches the fee ledger when part of it is deducted or it
+// is settled against dues.
+type Deposit struct {
+	ID            					uint `json:"id"`
+	StudentId								uint `json:"student_id" validate:"nonzero"`
+	HostelStudentId					uint `json:"hostel_student_id"`
+	HostelId								uint `json:"hostel_id"`
+	BatchStandardStudentId	uint `json:"batch_standard_student_id"`
+	Amount       						float64 `json:"amount" validate:"nonzero"`
+	Deducted 								float64 `json:"deducted"`
+	Settled 								float64 `json:"settled"`
+	Refunded 								float64 `json:"refunded"`
+	Status 									string `json:"status" gorm:"default:'Held'"`
+	PaidBy 									string `json:"paid_by" validate:"nonzero"`
+	PaymentMode 						string `json:"payment_mode" validate:"nonzero"`
+	SettledAt 							*time.Time `json:"settled_at"`
+	UserID									int `json:"user_id"`
+	DepositDeductions 			[]DepositDeduction `json:"deductions"`
+	CreatedAt 							time.Time
+	UpdatedAt 							time.Time
+  DeletedAt 							gorm.DeletedAt `gorm:"index"`
+}
+
+type DepositDeduction struct {
+	ID            	uint `json:"id"`
+	DepositId 			uint `json:"deposit_id" gorm:"index"`
+	Amount       		float64 `json:"amount" validate:"nonzero"`
+	Reason 					string `json:"reason" validate:"nonzero"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+type HostelDepositSummary struct {
+	HostelId 	uint `json:"hostel_id"`
+	Name 			string `json:"name"`
+	Deposits 	int64 `json:"deposits"`
+	Held 			float64 `json:"held"`
+}
+
+func migrateDeposit() {
+	fmt.Println("migrating deposit..")
+	err := db.Driver.AutoMigrate(&Deposit{}, &DepositDeduction{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func NewDeposit(depositData map[string]interface{}, student *Student) *Deposit {
+	deposit := &Deposit{StudentId: student.ID}
+	deposit.Assign(depositData)
+	return deposit
+}
+
+func (d *Deposit) Validate() error {
+	if errs := validator.Validate(d); errs != nil {
+		return errs
+	}
+	if d.Amount < 0.0 {
+		return validator.ErrorMap{"Amount": validator.ErrorArray{validator.TextErr{Err: swapErr.ErrNotPositive}}}
+	}
+	if (d.HostelStudentId == 0) == (d.BatchStandardStudentId == 0) {
+		return validator.ErrorMap{"HostelStudentId": validator.ErrorArray{swapErr.ErrDepositFor}}
+	}
+
+	if d.HostelStudentId > 0 {
+		hostelStudent := &HostelStudent{ID: d.HostelStudentId}
+		if err := hostelStudent.Find(); err != nil || hostelStudent.StudentId != d.StudentId {
+			return validator.ErrorMap{"HostelStudentId": validator.ErrorArray{swapErr.ErrDepositFor}}
+		}
+		d.HostelId = hostelStudent.HostelId
+	} else {
+		batchStandardStudent := &BatchStandardStudent{ID: d.BatchStandardStudentId}
+		if err := batchStandardStudent.Find(); err != nil || batchStandardStudent.StudentId != d.StudentId {
+			return validator.ErrorMap{"BatchStandardStudentId": validator.ErrorArray{swapErr.ErrDepositFor}}
+		}
+	}
+	return nil
+}
+
+// Validate requires an amount above zero, as a negative deduction would
+// give the deposit back through the ledger.
+func (dd *DepositDeduction) Validate() error {
+	if errs := validator.Validate(dd); errs != nil {
+		return errs
+	}
+	if dd.Amount < 0.0 {
+		return validator.ErrorMap{"Amount": validator.ErrorArray{validator.TextErr{Err: swapErr.ErrNotPositive}}}
+	}
+	return nil
+}
+
+func (d *Deposit) Assign(depositData map[string]interface{}) {
+	if hostelStudentId, ok := depositData["hostel_student_id"]; ok {
+		d.HostelStudentId = uint(hostelStudentId.(float64))
+	}
+
+	if batchStandardStudentId, ok := depositData["batch_standard_student_id"]; ok {
+		d.BatchStandardStudentId = uint(batchStandardStudentId.(float64))
+	}
+
+	if amount, ok := depositData["amount"]; ok {
+		d.Amount = amount.(float64)
+	}
+
+	if paidBy, ok := depositData["paid_by"]; ok {
+		d.PaidBy = paidBy.(string)
+	}
+
+	if paymentMode, ok := depositData["payment_mode"]; ok {
+		d.PaymentMode = paymentMode.(string)
+	}
+}
+
+// Available is the part of the deposit still held for the student.
+func (d *Deposit) Available() float64 {
+	return math.Round((d.Amount - d.Deducted - d.Settled - d.Refunded) * 100) / 100
+}
+
+func (d *Deposit) All(studentId uint) ([]Deposit, error) {
+	var deposits []Deposit
+	err := db.Driver.Preload("DepositDeductions").Where("student_id = ?", studentId).Order("id desc").Find(&deposits).Error
+	return deposits, err
+}
+
+func (d *Deposit) Find() error {
+	err := db.Driver.Preload("DepositDeductions").First(d, "ID = ?", d.ID).Error
+	return err
+}
+
+func (d *Deposit) Create() error {
+	err := db.Driver.Create(d).Error
+	return err
+}
+
+func (d *Deposit) Update() error {
+	err := db.Driver.Omit("DepositDeductions").Save(d).Error
+	return err
+}
+
+// Deduct withholds part of a held deposit, for damages and the like. The
+// deduction is posted on the ledger as a Security Deposit charge paid from the
+// deposit, so it shows as income without changing the student's balance.
+func (d *Deposit) Deduct(amount float64, reason string, userId int) (*DepositDeduction, error) {
+	if d.Status != "Held" {
+		return nil, swapErr.ErrDepositSettled
+	}
+	deduction := &DepositDeduction{DepositId: d.ID, Amount: amount, Reason: reason, UserID: userId}
+	if err := deduction.Validate(); err != nil {
+		return nil, err
+	}
+	if amount > d.Available() {
+		return nil, swapErr.ErrDeductionExceedsDeposit
+	}
+
+	deducted := d.Deducted
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(deduction).Error; err != nil {
+			return err
+		}
+		if _, err := d.post(tx, "Deposit deduction", "debit", reason, amount); err != nil {
+			return err
+		}
+		if _, err := d.post(tx, "Deposit deduction paid from deposit", "credit", reason, amount); err != nil {
+			return err
+		}
+		d.Deducted = deducted + amount
+		return tx.Omit("DepositDeductions").Save(d).Error
+	})
+	if err != nil {
+		d.Deducted = deducted
+		return nil, err
+	}
+	d.DepositDeductions = append(d.DepositDeductions, *deduction)
+	return deduction, saveStudentBalance(d.StudentId)
+}
+
+// Settle closes the deposit on exit. What is held first clears the student's
+// outstanding dues, posted as a Security Deposit credit on the ledger, and the
+// rest is refunded.
+func (d *Deposit) Settle(userId int) error {
+	if d.Status != "Held" {
+		return swapErr.ErrDepositSettled
+	}
+
+	student := &Student{ID: d.StudentId}
+	if err := student.Find(); err != nil {
+		return err
+	}
+	if err := student.SaveBalance(); err != nil {
+		return err
+	}
+
+	available := d.Available()
+	settled := 0.0
+	if student.Balance < 0.0 {
+		settled = math.Min(available, -student.Balance)
+	}
+
+	now := time.Now()
+	deposit := *d
+	d.Settled = d.Settled + settled
+	d.Refunded = d.Refunded + available - settled
+	d.Status = "Settled"
+	d.SettledAt = &now
+	d.UserID = userId
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		if settled > 0.0 {
+			if _, err := d.post(tx, "Deposit adjusted against dues", "credit", "", settled); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("DepositDeductions").Save(d).Error
+	})
+	if err != nil {
+		*d = deposit
+		return err
+	}
+	return saveStudentBalance(d.StudentId)
+}
+
+// post puts an entry of the deposit on the student's ledger, under the
+// Security Deposit category and against the enrollment the deposit is for.
+func (d *Deposit) post(tx *gorm.DB, name string, transactionType string, reason string, amount float64) (*Transaction, error) {
+	transactionCategory, err := depositCategory(tx)
+	if err != nil {
+		return nil, err
+	}
+	transactionData := map[string]interface{}{"name": name, "student_id": float64(d.StudentId),
+		"hostel_student_id": float64(d.HostelStudentId), "batch_standard_student_id": float64(d.BatchStandardStudentId),
+		"transaction_category_id": float64(transactionCategory.ID), "is_cleared": true, "transaction_type": transactionType,
+		"payment_mode": "Deposit", "paid_by": "-", "reason": reason, "amount": amount}
+	transaction := NewTransaction(transactionData, Student{ID: d.StudentId})
+	if err := transaction.create(tx); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func depositCategory(tx *gorm.DB) (*TransactionCategory, error) {
+	tc := &TransactionCategory{}
+	err := tx.Where(TransactionCategory{Name: "Security Deposit"}).FirstOrCreate(tc).Error
+	return tc, err
+}
+
+// HeldByHostel totals the deposits still held, hostel by hostel.
+func HeldByHostel() ([]HostelDepositSummary, error) {
+	summaries := []HostelDepositSummary{}
+	err := db.Driver.Model(&Deposit{}).
+		Select("deposits.hostel_id, hostels.name, count(deposits.id) as deposits, sum(deposits.amount - deposits.deducted - deposits.settled - deposits.refunded) as held").
+		Joins("join hostels on hostels.id = deposits.hostel_id").
+		Where("deposits.status = ? and deposits.hostel_id > 0", "Held").
+		Group("deposits.hostel_id, hostels.name").Order("hostels.name").Scan(&summaries).Error
+	return summaries, err
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+type FeeRevision struct {
+	ID            	uint `json:"id"`
+	BatchStandardId uint `json:"batch_standard_id" validate:"nonzero" gorm:"index"`
+	PreviousFee 		float64 `json:"previous_fee"`
+	Fee							float64 `json:"fee" validate:"nonzero"`
+	EffectiveFrom 	time.Time `json:"effective_from"`
+	Reason 					string `json:"reason"`
+	Status 					string `json:"status" gorm:"default:'Draft'"`
+	RebillEnrolled 	bool `json:"rebill_enrolled"`
+	UserID					int `json:"user_id"`
+	AppliedBy 			int `json:"applied_by"`
+	AppliedAt 			*time.Time `json:"applied_at"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+type FeeRevisionImpact struct {
+	BatchStandardStudentId 	uint `json:"batch_standard_student_id"`
+	StudentId 							uint `json:"student_id"`
+	Student 								Student `json:"student"`
+	CurrentFee 							float64 `json:"current_fee"`
+	NewFee 									float64 `json:"new_fee"`
+	Difference 							float64 `json:"difference"`
+	Prorated 								bool `json:"prorated"`
+}
+
+func migrateFeeRevision() {
+	fmt.Println("migrating fee revision..")
+	err := db.Driver.AutoMigrate(&FeeRevision{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func NewFeeRevision(feeRevisionData map[string]interface{}, batchStandard *BatchStandard) *FeeRevision {
+	feeRevision := &FeeRevision{BatchStandardId: batchStandard.ID, PreviousFee: batchStandard.Fee, EffectiveFrom: startOfDay(time.Now())}
+	feeRevision.Assign(feeRevisionData)
+	return feeRevision
+}
+
+func (fr *FeeRevision) Validate() error {
+	if errs := validator.Validate(fr); errs != nil {
+		return errs
+	} else {
+		return nil
+	}
+}
+
+func (fr *FeeRevision) Assign(feeRevisionData map[string]interface{}) {
+	if fee, ok := feeRevisionData["fee"]; ok {
+		fr.Fee = fee.(float64)
+	}
+
+	if effectiveFrom, ok := feeRevisionData["effective_from"]; ok {
+		if date, err := time.ParseInLocation("2006-01-02", effectiveFrom.(string), time.Local); err == nil {
+			fr.EffectiveFrom = date
+		}
+	}
+
+	if reason, ok := feeRevisionData["reason"]; ok {
+		fr.Reason = reason.(string)
+	}
+}
+
+func (fr *FeeRevision) All(batchStandardId uint) ([]FeeRevision, error) {
+	var feeRevisions []FeeRevision
+	err := db.Driver.Where("batch_standard_id = ?", batchStandardId).Order("effective_from desc, id desc").Find(&feeRevisions).Error
+	return feeRevisions, err
+}
+
+func (fr *FeeRevision) Find() error {
+	err := db.Driver.First(fr, "ID = ?", fr.ID).Error
+	return err
+}
+
+func (fr *FeeRevision) Create() error {
+	err := db.Driver.Create(fr).Error
+	return err
+}
+
+// FeeOn resolves the fee of the batch standard on date from its applied
+// revisions: the latest one effective by then, or the fee before the first
+// one when none was.
+func (bs *BatchStandard) FeeOn(date time.Time) (float64, error) {
+	feeRevision := &FeeRevision{}
+	err := db.Driver.Where("batch_standard_id = ? and status = ? and effective_from <= ?", bs.ID, "Applied", date).
+		Order("effective_from desc, id desc").First(feeRevision).Error
+	if err == nil {
+		return feeRevision.Fee, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return 0.0, err
+	}
+	err = db.Driver.Where("batch_standard_id = ? and status = ?", bs.ID, "Applied").
+		Order("effective_from, id").First(feeRevision).Error
+	if err == gorm.ErrRecordNotFound {
+		return bs.Fee, nil
+	}
+	if err != nil {
+		return 0.0, err
+	}
+	return feeRevision.PreviousFee, nil
+}
+
+// Preview lists the enrolled students whose fee differs from the revised fee
+// and the differential each would be billed on apply, prorated for the part
+// of the fiscal year from the effective date, or from the enrollment when it
+// came later.
+func (fr *FeeRevision) Preview() ([]FeeRevisionImpact, error) {
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Preload("Student").Where("batch_standard_id = ?", fr.BatchStandardId).Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	yearStart, yearEnd := FiscalYearBounds(fr.EffectiveFrom)
+	yearEnd = yearEnd.AddDate(0, 0, 1)
+	impacts := []FeeRevisionImpact{}
+	for _, bss := range batchStandardStudents {
+		from := fr.EffectiveFrom
+		if enrolledOn := startOfDay(bss.CreatedAt); enrolledOn.After(from) {
+			from = enrolledOn
+		}
+		if !from.Before(yearEnd) {
+			continue
+		}
+		difference, prorated := prorate(fr.Fee - bss.Fee, yearStart, yearEnd, from, yearEnd)
+		difference = math.Round(difference * 100) / 100
+		if difference == 0.0 {
+			continue
+		}
+		impacts = append(impacts, FeeRevisionImpact{BatchStandardStudentId: bss.ID, StudentId: bss.StudentId, Student: bss.Student,
+			CurrentFee: bss.Fee, NewFee: fr.Fee, Difference: difference, Prorated: prorated})
+	}
+	return impacts, nil
+}
+
+// Apply makes the revised fee the fee of the batch standard. With
+// rebillEnrolled every enrolled student is also billed the prorated
+// differential as a debit (or credit for a reduction); otherwise only new
+// enrollments pay it. Revisions are applied once they are effective and in
+// date order, so one dated before an applied revision is refused.
+func (fr *FeeRevision) Apply(rebillEnrolled bool, userId int) ([]FeeRevisionImpact, error) {
+	if fr.Status != "Draft" {
+		return nil, swapErr.ErrFeeRevisionApplied
+	}
+	if fr.EffectiveFrom.After(time.Now()) {
+		return nil, swapErr.ErrFeeRevisionNotDue
+	}
+	var later int64
+	err := db.Driver.Model(&FeeRevision{}).Where("batch_standard_id = ? and status = ? and effective_from > ?",
+		fr.BatchStandardId, "Applied", fr.EffectiveFrom).Count(&later).Error
+	if err != nil {
+		return nil, err
+	}
+	if later > 0 {
+		return nil, swapErr.ErrFeeRevisionSuperseded
+	}
+
+	batchStandard := &BatchStandard{ID: fr.BatchStandardId}
+	if err := batchStandard.Find(); err != nil {
+		return nil, err
+	}
+	transactionCategory, err := batchStandard.GetTransactionCategory()
+	if err != nil {
+		return nil, err
+	}
+
+	impacts := []FeeRevisionImpact{}
+	if rebillEnrolled {
+		if impacts, err = fr.Preview(); err != nil {
+			return nil, err
+		}
+	}
+
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		for _, impact := range impacts {
+			transaction := &Transaction{Name: fmt.Sprintf("Fee Revision from %s", fr.EffectiveFrom.Format("02 Jan 2006")),
+				StudentId: impact.StudentId, TransactionCategoryId: transactionCategory.ID,
+				BatchStandardStudentId: impact.BatchStandardStudentId, IsCleared: true, PaidBy: "-", PaymentMode: "-",
+				TransactionType: "debit", Amount: impact.Difference, Reason: fr.Reason}
+			if impact.Difference < 0.0 {
+				transaction.TransactionType = "credit"
+				transaction.Amount = -impact.Difference
+			}
+			if err := transaction.create(tx); err != nil {
+				return err
+			}
+			err := tx.Model(&BatchStandardStudent{}).Where("id = ?", impact.BatchStandardStudentId).Update("fee", fr.Fee).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		fr.PreviousFee = batchStandard.Fee
+		fr.Status = "Applied"
+		fr.RebillEnrolled = rebillEnrolled
+		fr.AppliedBy = userId
+		fr.AppliedAt = &now
+		if err := tx.Save(fr).Error; err != nil {
+			return err
+		}
+		return tx.Model(batchStandard).Update("fee", fr.Fee).Error
+	})
+	if err != nil {
+		fr.Status = "Draft"
+		fr.AppliedAt = nil
+		return nil, err
+	}
+
+	for _, impact := range impacts {
+		saveStudentBalance(impact.StudentId)
+	}
+	return impacts, nil
+}
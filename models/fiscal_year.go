@@ -0,0 +1,314 @@
+package models
+
+import (
+	"fmt"
+	"swapnil-ex/constants"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+type FiscalYear struct {
+	ID            	uint `json:"id"`
+	Name     				string `json:"name" validate:"nonzero"`
+	StartDate 			time.Time `json:"start_date"`
+	EndDate 				time.Time `json:"end_date"`
+	IsClosed 				bool `json:"is_closed" gorm:"default:false"`
+	OpeningPosted 	bool `json:"opening_posted" gorm:"default:false"`
+	ClosedAt 				*time.Time `json:"closed_at"`
+	ClosedBy 				int `json:"closed_by"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+type FiscalYearBalance struct {
+	ID            	uint `json:"id"`
+	FiscalYearId 		uint `json:"fiscal_year_id" gorm:"index"`
+	StudentId				uint `json:"student_id"`
+	OpeningBalance 	float64 `json:"opening_balance"`
+	Debits 					float64 `json:"debits"`
+	Credits 				float64 `json:"credits"`
+	ClosingBalance 	float64 `json:"closing_balance"`
+	Student 				Student `json:"student"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+func migrateFiscalYear() {
+	fmt.Println("migrating fiscal year..")
+	err := db.Driver.AutoMigrate(&FiscalYear{}, &FiscalYearBalance{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// FiscalYearBounds returns the first and last day of the fiscal year
+// containing date. Fiscal years start on the first of YEAR_START_MONTH.
+func FiscalYearBounds(date time.Time) (time.Time, time.Time) {
+	year := date.Year()
+	if int(date.Month()) < constants.YEAR_START_MONTH {
+		year = year - 1
+	}
+	start := time.Date(year, time.Month(constants.YEAR_START_MONTH), 1, 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(1, 0, -1)
+}
+
+func NewFiscalYear(fiscalYearData map[string]interface{}) *FiscalYear {
+	fiscalYear := &FiscalYear{}
+	fiscalYear.StartDate, fiscalYear.EndDate = FiscalYearBounds(time.Now())
+	fiscalYear.Assign(fiscalYearData)
+	if fiscalYear.Name == "" {
+		fiscalYear.Name = fiscalYear.defaultName()
+	}
+	return fiscalYear
+}
+
+func (fy *FiscalYear) Validate() error {
+	if errs := validator.Validate(fy); errs != nil {
+		return errs
+	}
+	if !fy.EndDate.After(fy.StartDate) {
+		return validator.ErrorMap{"EndDate": validator.ErrorArray{swapErr.ErrInvalidFiscalYear}}
+	}
+
+	var count int64
+	db.Driver.Model(&FiscalYear{}).Where("id <> ? and start_date <= ? and end_date >= ?", fy.ID, fy.EndDate, fy.StartDate).Count(&count)
+	if count > 0 {
+		return validator.ErrorMap{"StartDate": validator.ErrorArray{swapErr.ErrInvalidFiscalYear}}
+	}
+	return nil
+}
+
+func (fy *FiscalYear) Assign(fiscalYearData map[string]interface{}) {
+	if name, ok := fiscalYearData["name"]; ok {
+		fy.Name = name.(string)
+	}
+
+	if startDate, ok := fiscalYearData["start_date"]; ok {
+		if date, err := time.ParseInLocation("2006-01-02", startDate.(string), time.Local); err == nil {
+			fy.StartDate = date
+			fy.EndDate = date.AddDate(1, 0, -1)
+		}
+	}
+
+	if endDate, ok := fiscalYearData["end_date"]; ok {
+		if date, err := time.ParseInLocation("2006-01-02", endDate.(string), time.Local); err == nil {
+			fy.EndDate = date
+		}
+	}
+}
+
+func (fy *FiscalYear) defaultName() string {
+	return fmt.Sprintf("%d-%02d", fy.StartDate.Year(), fy.EndDate.Year() % 100)
+}
+
+var FiscalYearListFields = ListFields{"id": "id", "name": "name", "start_date": "start_date", "end_date": "end_date",
+	"is_closed": "is_closed", "opening_posted": "opening_posted", "closed_at": "closed_at", "closed_by": "closed_by",
+	"created_at": "created_at", "updated_at": "updated_at"}
+
+func (fy *FiscalYear) All(lq *ListQuery) ([]FiscalYear, error) {
+	var fiscalYears []FiscalYear
+	err := db.Driver.Scopes(lq.Scope).Find(&fiscalYears).Error
+	return fiscalYears, err
+}
+
+func (fy *FiscalYear) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&FiscalYear{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
+func (fy *FiscalYear) Find() error {
+	err := db.Driver.First(fy, "ID = ?", fy.ID).Error
+	return err
+}
+
+func (fy *FiscalYear) Create() error {
+	err := db.Driver.Create(fy).Error
+	return err
+}
+
+func (fy *FiscalYear) Update() error {
+	err := db.Driver.Save(fy).Error
+	return err
+}
+
+// periodEnd is the exclusive end of the fiscal year.
+func (fy *FiscalYear) periodEnd() time.Time {
+	return fy.EndDate.AddDate(0, 0, 1)
+}
+
+func (fy *FiscalYear) Contains(date time.Time) bool {
+	return !date.Before(fy.StartDate) && date.Before(fy.periodEnd())
+}
+
+func (fy *FiscalYear) Scope(query *gorm.DB) *gorm.DB {
+	return query.Where("created_at >= ? and created_at < ?", fy.StartDate, fy.periodEnd())
+}
+
+// InClosedFiscalYear reports whether date falls in a fiscal year that has
+// been closed, whose transactions are read-only.
+func InClosedFiscalYear(date time.Time) bool {
+	return inClosedFiscalYear(db.Driver, date)
+}
+
+func inClosedFiscalYear(tx *gorm.DB, date time.Time) bool {
+	var fiscalYears []FiscalYear
+	tx.Where("is_closed = ?", true).Find(&fiscalYears)
+	for _, fiscalYear := range fiscalYears {
+		if fiscalYear.Contains(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasClosedYearTransactions reports whether any transaction of the student,
+// trashed ones included, falls in a closed fiscal year.
+func hasClosedYearTransactions(tx *gorm.DB, studentId uint) (bool, error) {
+	var fiscalYears []FiscalYear
+	if err := tx.Where("is_closed = ?", true).Find(&fiscalYears).Error; err != nil {
+		return false, err
+	}
+	for _, fiscalYear := range fiscalYears {
+		var count int64
+		query := tx.Unscoped().Model(&Transaction{}).Where("student_id = ?", studentId)
+		if err := fiscalYear.Scope(query).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (fy *FiscalYear) next() (*FiscalYear, error) {
+	next := &FiscalYear{}
+	err := db.Driver.Where("start_date = ?", fy.periodEnd()).Limit(1).Find(next).Error
+	if err != nil || next.ID > 0 {
+		return next, err
+	}
+
+	next.StartDate = fy.periodEnd()
+	next.EndDate = next.StartDate.AddDate(1, 0, -1)
+	next.Name = next.defaultName()
+	return next, next.Create()
+}
+
+// Balances returns each student's opening, debits, credits and closing
+// balance for the year. Closed years return the snapshot taken at close.
+func (fy *FiscalYear) Balances() ([]FiscalYearBalance, error) {
+	if fy.IsClosed {
+		var balances []FiscalYearBalance
+		err := db.Driver.Preload("Student").Where("fiscal_year_id = ?", fy.ID).Order("student_id").Find(&balances).Error
+		return balances, err
+	}
+
+	var transactions []Transaction
+	err := db.Driver.Where("created_at < ?", fy.periodEnd()).Order("student_id").Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []FiscalYearBalance{}
+	index := map[uint]int{}
+	for _, transaction := range transactions {
+		opening := transaction.IsOpeningBalance
+		if opening && transaction.FiscalYearId != fy.ID {
+			continue
+		}
+		if !opening && transaction.CreatedAt.Before(fy.StartDate) {
+			if fy.OpeningPosted {
+				continue
+			}
+			opening = true
+		}
+
+		i, ok := index[transaction.StudentId]
+		if !ok {
+			i = len(balances)
+			index[transaction.StudentId] = i
+			balances = append(balances, FiscalYearBalance{FiscalYearId: fy.ID, StudentId: transaction.StudentId})
+		}
+
+		amount := transaction.Amount
+		if transaction.TransactionType == "debit" {
+			amount = -amount
+		}
+		if opening {
+			balances[i].OpeningBalance = balances[i].OpeningBalance + amount
+		} else if amount < 0.0 {
+			balances[i].Debits = balances[i].Debits - amount
+		} else {
+			balances[i].Credits = balances[i].Credits + amount
+		}
+		balances[i].ClosingBalance = balances[i].ClosingBalance + amount
+	}
+	return balances, nil
+}
+
+// Close snapshots every student's closing balance and carries it forward as
+// an opening balance entry into the next fiscal year, creating that year if
+// needed. Transactions of a closed year can no longer be changed.
+func (fy *FiscalYear) Close(userId int) (*FiscalYear, error) {
+	if fy.IsClosed {
+		return nil, swapErr.ErrFiscalYearClosed
+	}
+	if !fy.periodEnd().Before(time.Now()) {
+		return nil, swapErr.ErrFiscalYearNotEnded
+	}
+
+	var count int64
+	db.Driver.Model(&FiscalYear{}).Where("start_date < ? and is_closed = ?", fy.StartDate, false).Count(&count)
+	if count > 0 {
+		return nil, swapErr.ErrPreviousFiscalYearOpen
+	}
+
+	balances, err := fy.Balances()
+	if err != nil {
+		return nil, err
+	}
+	next, err := fy.next()
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		for i := range balances {
+			if err := tx.Omit("Student").Create(&balances[i]).Error; err != nil {
+				return err
+			}
+			if balances[i].ClosingBalance == 0.0 {
+				continue
+			}
+
+			opening := &Transaction{Name: "Opening Balance", StudentId: balances[i].StudentId, FiscalYearId: next.ID,
+				IsOpeningBalance: true, IsCleared: true, PaidBy: "-", PaymentMode: "-", TransactionType: "credit",
+				Amount: balances[i].ClosingBalance, ReceiptId: fmt.Sprintf("OB%s%d", next.StartDate.Format("20060102"), balances[i].StudentId),
+				CreatedAt: next.StartDate}
+			if opening.Amount < 0.0 {
+				opening.TransactionType = "debit"
+				opening.Amount = -opening.Amount
+			}
+			if err := tx.Omit("Student").Create(opening).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		fy.IsClosed = true
+		fy.ClosedAt = &now
+		fy.ClosedBy = userId
+		if err := tx.Save(fy).Error; err != nil {
+			return err
+		}
+		next.OpeningPosted = true
+		return tx.Save(next).Error
+	})
+	return next, err
+}
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"swapnil-ex/models/db"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+type Guardian struct {
+	ID            	uint `json:"id"`
+	Name     				string `json:"name" validate:"nonzero"`
+	Relationship 		string `json:"relationship" validate:"nonzero"`
+	Phone 					string `json:"phone" gorm:"index" validate:"nonzero,min=10,max=12"`
+	AlternatePhone 	string `json:"alternate_phone"`
+	WhNumber 				string `json:"wh_number"`
+	Email 					string `json:"email"`
+	Address 				string `json:"address"`
+	Occupation 			string `json:"occupation"`
+	Income 					float64 `json:"income"`
+	IsPrimary 			bool `json:"is_primary" gorm:"->;-:migration"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+// StudentGuardian links a student to a guardian. Siblings share the same
+// guardian rows. Each student has one primary contact.
+type StudentGuardian struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" gorm:"uniqueIndex:idx_student_guardian"`
+	GuardianId 			uint `json:"guardian_id" gorm:"uniqueIndex:idx_student_guardian;index"`
+	IsPrimary 			bool `json:"is_primary" gorm:"default:false"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+}
+
+func migrateGuardian() {
+	fmt.Println("migrating guardian..")
+	err := db.Driver.AutoMigrate(&Guardian{}, &StudentGuardian{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+	splitStudentGuardians()
+}
+
+// splitStudentGuardians moves the parent columns of students that have no
+// guardian yet into guardians, sharing one guardian between siblings.
+func splitStudentGuardians() {
+	var students []Student
+	db.Driver.Where("parent_name <> '' and id not in (?)", db.Driver.Model(&StudentGuardian{}).Select("student_id")).Find(&students)
+	for i := range students {
+		if err := students[i].linkParentGuardian(db.Driver); err != nil {
+			fmt.Println("linkParentGuardian", students[i].ID, err)
+		}
+	}
+}
+
+// linkParentGuardian links the student to the guardian with the same name
+// and phone as its parent columns, creating the guardian when it is the
+// first of its siblings.
+func (s *Student) linkParentGuardian(tx *gorm.DB) error {
+	if strings.TrimSpace(s.ParentName) == "" {
+		return nil
+	}
+	guardian := &Guardian{}
+	err := tx.Where("lower(name) = lower(?) and phone = ?", strings.TrimSpace(s.ParentName), s.ContactNumber).
+		Limit(1).Find(guardian).Error
+	if err != nil {
+		return err
+	}
+	if guardian.ID == 0 {
+		guardian = &Guardian{Name: strings.TrimSpace(s.ParentName), Relationship: "Parent", Phone: s.ContactNumber,
+			WhNumber: s.WhNumber, Occupation: s.ParentOccupation}
+		if err := tx.Create(guardian).Error; err != nil {
+			return err
+		}
+	}
+	return guardian.link(tx, s.ID, true)
+}
+
+// syncPrimaryGuardian carries the parent columns of the student over to its
+// primary guardian, and from there to the siblings sharing it. A student
+// without guardians is linked to one.
+func (s *Student) syncPrimaryGuardian(tx *gorm.DB) error {
+	guardian := &Guardian{}
+	err := tx.Select("guardians.*, student_guardians.is_primary").Joins("join student_guardians on student_guardians.guardian_id = guardians.id").
+		Where("student_guardians.student_id = ? and student_guardians.is_primary = ?", s.ID, true).Limit(1).Find(guardian).Error
+	if err != nil {
+		return err
+	}
+	if guardian.ID == 0 {
+		return s.linkParentGuardian(tx)
+	}
+
+	fields := map[*string]string{&guardian.Name: strings.TrimSpace(s.ParentName), &guardian.Phone: s.ContactNumber,
+		&guardian.WhNumber: s.WhNumber, &guardian.Occupation: s.ParentOccupation}
+	changed := false
+	for field, value := range fields {
+		if value != "" && *field != value {
+			*field = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := tx.Save(guardian).Error; err != nil {
+		return err
+	}
+	return guardian.syncStudents(tx)
+}
+
+// syncStudents copies the guardian's name and numbers into the parent columns
+// of the students it is the primary contact of, or only of studentIds when
+// given.
+func (g *Guardian) syncStudents(tx *gorm.DB, studentIds ...uint) error {
+	query := tx.Model(&StudentGuardian{}).Where("guardian_id = ? and is_primary = ?", g.ID, true)
+	if len(studentIds) > 0 {
+		query = query.Where("student_id in (?)", studentIds)
+	}
+	var ids []uint
+	if err := query.Pluck("student_id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	columns := map[string]interface{}{}
+	for column, value := range map[string]string{"parent_name": g.Name, "contact_number": g.Phone, "wh_number": g.WhNumber,
+		"parent_occupation": g.Occupation} {
+		if value != "" {
+			columns[column] = value
+		}
+	}
+	if err := tx.Model(&Student{}).Where("id in (?)", ids).Updates(columns).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := indexStudent(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewGuardian(guardianData map[string]interface{}) *Guardian {
+	guardian := &Guardian{}
+	guardian.Assign(guardianData)
+	return guardian
+}
+
+func (g *Guardian) Validate() error {
+	if errs := validator.Validate(g); errs != nil {
+		return errs
+	} else {
+		return nil
+	}
+}
+
+func (g *Guardian) Assign(guardianData map[string]interface{}) {
+	fields := map[string]*string{"name": &g.Name, "relationship": &g.Relationship, "phone": &g.Phone,
+		"alternate_phone": &g.AlternatePhone, "wh_number": &g.WhNumber, "email": &g.Email, "address": &g.Address,
+		"occupation": &g.Occupation}
+	for key, field := range fields {
+		if value, ok := guardianData[key]; ok {
+			*field = value.(string)
+		}
+	}
+
+	if income, ok := guardianData["income"]; ok {
+		g.Income = income.(float64)
+	}
+}
+
+// All returns the guardians of a student, primary contact first.
+func (g *Guardian) All(studentId uint) ([]Guardian, error) {
+	var guardians []Guardian
+	err := db.Driver.Select("guardians.*, student_guardians.is_primary").
+		Joins("join student_guardians on student_guardians.guardian_id = guardians.id").
+		Where("student_guardians.student_id = ?", studentId).
+		Order("student_guardians.is_primary desc, guardians.id").Find(&guardians).Error
+	return guardians, err
+}
+
+func (g *Guardian) Find() error {
+	err := db.Driver.First(g, "ID = ?", g.ID).Error
+	return err
+}
+
+func (g *Guardian) Create() error {
+	err := db.Driver.Create(g).Error
+	return err
+}
+
+// Update saves the guardian and carries its name and numbers over to the
+// students it is the primary contact of.
+func (g *Guardian) Update() error {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(g).Error; err != nil {
+			return err
+		}
+		return g.syncStudents(tx)
+	})
+}
+
+// Students returns the students, siblings, linked to the guardian.
+func (g *Guardian) Students() ([]Student, error) {
+	var students []Student
+	err := db.Driver.Joins("join student_guardians on student_guardians.student_id = students.id").
+		Where("student_guardians.guardian_id = ?", g.ID).Order("students.id").Find(&students).Error
+	return students, err
+}
+
+// Link adds the guardian to the student. The first guardian of a student is
+// always its primary contact.
+func (g *Guardian) Link(studentId uint, isPrimary bool) error {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		return g.link(tx, studentId, isPrimary)
+	})
+}
+
+func (g *Guardian) link(tx *gorm.DB, studentId uint, isPrimary bool) error {
+	var count int64
+	if err := tx.Model(&StudentGuardian{}).Where("student_id = ?", studentId).Count(&count).Error; err != nil {
+		return err
+	}
+	studentGuardian := &StudentGuardian{StudentId: studentId, GuardianId: g.ID}
+	if err := tx.Where(studentGuardian).FirstOrCreate(studentGuardian).Error; err != nil {
+		return err
+	}
+	if isPrimary || count == 0 {
+		return setPrimaryGuardian(tx, studentId, g.ID)
+	}
+	return nil
+}
+
+func (g *Guardian) SetPrimary(studentId uint) error {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		return setPrimaryGuardian(tx, studentId, g.ID)
+	})
+}
+
+func setPrimaryGuardian(tx *gorm.DB, studentId uint, guardianId uint) error {
+	result := tx.Model(&StudentGuardian{}).Where("student_id = ? and guardian_id = ?", studentId, guardianId).Update("is_primary", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	err := tx.Model(&StudentGuardian{}).Where("student_id = ? and guardian_id <> ?", studentId, guardianId).Update("is_primary", false).Error
+	if err != nil {
+		return err
+	}
+	guardian := &Guardian{}
+	if err := tx.First(guardian, "id = ?", guardianId).Error; err != nil {
+		return err
+	}
+	return guardian.syncStudents(tx, studentId)
+}
+
+// Unlink removes the guardian from the student. When it was the primary
+// contact another guardian of the student takes over.
+func (g *Guardian) Unlink(studentId uint) error {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		studentGuardian := &StudentGuardian{}
+		err := tx.Where("student_id = ? and guardian_id = ?", studentId, g.ID).First(studentGuardian).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(studentGuardian).Error; err != nil {
+			return err
+		}
+		if !studentGuardian.IsPrimary {
+			return nil
+		}
+
+		next := &StudentGuardian{}
+		err = tx.Where("student_id = ?", studentId).Order("id").Limit(1).Find(next).Error
+		if err != nil || next.ID == 0 {
+			return err
+		}
+		return setPrimaryGuardian(tx, studentId, next.GuardianId)
+	})
+}
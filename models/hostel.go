@@ -15,6 +15,7 @@ type Hostel struct {
 	Rector      		string `json:"rector"`	
 	ContactNumber 	int64  `json:"contact_number" gorm:"contact_number"`
 	Rate     				float64 	`json:"rate" validate:"nonzero"`
+	BillingCycle 		string `json:"billing_cycle" validate:"regexp=^(monthly|termly|yearly)?$"`
 	HostelRoomsCount int64 `json:"hostel_rooms_count" gorm:"default:0"`
 	HostelStudentsCount int64 `json:"hostel_students_count" gorm:"default:0"`
 	CreatedAt 			time.Time
@@ -24,14 +25,24 @@ type Hostel struct {
 
 func migrateHostel() {
 	fmt.Println("migrating student..")
+	hadBillingCycle := db.Driver.Migrator().HasColumn(&Hostel{}, "BillingCycle")
 	err := db.Driver.AutoMigrate(&Hostel{})
 	if err != nil {
 		panic("failed to migrate database")
 	}
+	// the rate of hostels from before billing cycles was charged once, on
+	// admission; they keep that until an admin sets a cycle
+	if !hadBillingCycle {
+		if err := db.Driver.Model(&Hostel{}).Where("1 = 1").Update("billing_cycle", "").Error; err != nil {
+			panic("failed to migrate database")
+		}
+	}
 }
 
+// NewHostel bills monthly unless billing_cycle says otherwise. An empty
+// cycle charges the rate once, when a student joins.
 func NewHostel(hostelData map[string]interface{}) *Hostel {
-	hostel := &Hostel{}
+	hostel := &Hostel{BillingCycle: "monthly"}
 	hostel.Assign(hostelData)
 	return hostel
 }
@@ -44,9 +55,9 @@ func (h *Hostel) Validate() error {
 	}
 }
 
-func (s *Hostel) HostelRooms() ([]HostelRoom, error){
+func (s *Hostel) HostelRooms(lq *ListQuery) ([]HostelRoom, error){
 	var hostelRooms []HostelRoom
-	err := db.Driver.Where("hostel_id = ?", s.ID).Find(&hostelRooms).Error
+	err := db.Driver.Where("hostel_id = ?", s.ID).Scopes(lq.Scope).Find(&hostelRooms).Error
 	return hostelRooms, err
 }
 
@@ -64,6 +75,10 @@ func (h *Hostel) Assign(hostelData map[string]interface{}) {
 		h.Rate = rate.(float64)
 	}
 
+	if billingCycle, ok := hostelData["billing_cycle"]; ok {
+		h.BillingCycle = billingCycle.(string)
+	}
+
 	if rector, ok := hostelData["rector"]; ok {
 		h.Rector = rector.(string)
 	}
@@ -74,12 +89,31 @@ func (h *Hostel) Assign(hostelData map[string]interface{}) {
 	}
 }
 
-func (h *Hostel) All() ([]Hostel, error) {
+var HostelListFields = ListFields{"id": "id", "name": "name", "rooms": "rooms", "rector": "rector", "contact_number": "contact_number",
+	"rate": "rate", "billing_cycle": "billing_cycle", "hostel_rooms_count": "hostel_rooms_count",
+	"hostel_students_count": "hostel_students_count", "created_at": "created_at", "updated_at": "updated_at"}
+
+var HostelRoomListFields = ListFields{"id": "id", "name": "name", "no_of_students": "no_of_students", "rate": "rate",
+	"hostel_id": "hostel_id", "hostel_students_count": "hostel_students_count", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (h *Hostel) All(lq *ListQuery) ([]Hostel, error) {
 	var hostels []Hostel
-	err := db.Driver.Find(&hostels).Error
+	err := db.Driver.Scopes(lq.Scope).Find(&hostels).Error
 	return hostels, err
 }
 
+func (h *Hostel) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&Hostel{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
+func (h *Hostel) CountHostelRooms(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&HostelRoom{}).Where("hostel_id = ?", h.ID).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
 func (h *Hostel) Find() error {
 	err := db.Driver.First(h, "ID = ?", h.ID).Error
 	return err
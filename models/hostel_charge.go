@@ -0,0 +1,155 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"swapnil-ex/constants"
+	"swapnil-ex/models/db"
+	"time"
+	"gorm.io/gorm"
+)
+
+type HostelCharge struct {
+	ID            		uint `json:"id"`
+	HostelStudentId 	uint `json:"hostel_student_id" gorm:"index"`
+	StudentId					uint `json:"student_id"`
+	HostelId					uint `json:"hostel_id"`
+	HostelRoomId    	uint `json:"hostel_room_id"`
+	TransactionId 		uint `json:"transaction_id"`
+	BillingCycle 			string `json:"billing_cycle"`
+	PeriodStart 			time.Time `json:"period_start" gorm:"index"`
+	PeriodEnd 				time.Time `json:"period_end"`
+	Rate 							float64 `json:"rate"`
+	Amount 						float64 `json:"amount"`
+	Prorated 					bool `json:"prorated"`
+	Student 					Student `json:"student"`
+	CreatedAt 				time.Time
+	UpdatedAt 				time.Time
+  DeletedAt 				gorm.DeletedAt `gorm:"index"`
+}
+
+func migrateHostelCharge() {
+	fmt.Println("migrating hostel charge..")
+	err := db.Driver.AutoMigrate(&HostelCharge{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// HostelBillingPeriod returns the [start, end) period of the given cycle that
+// contains date. Terms and years are counted from YEAR_START_MONTH.
+func HostelBillingPeriod(cycle string, date time.Time) (time.Time, time.Time) {
+	months := billingCycleMonths(cycle)
+	year, month, _ := date.Date()
+	offset := (int(month) - constants.YEAR_START_MONTH + 12) % 12
+	start := time.Date(year, month - time.Month(offset % months), 1, 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, months, 0)
+}
+
+func billingCycleMonths(cycle string) int {
+	switch cycle {
+	case "termly":
+		return constants.HOSTEL_TERM_MONTHS
+	case "yearly":
+		return 12
+	}
+	return 1
+}
+
+// BillHostelStudents posts the charge for the period containing date to every
+// hostel student (of one hostel when hostelId is set), all or none of them.
+// Hostels without a billing cycle are skipped. With dryRun nothing is saved
+// and the charges that would be posted are returned.
+func BillHostelStudents(date time.Time, hostelId uint, dryRun bool) ([]HostelCharge, error) {
+	var hostelStudents []HostelStudent
+	query := db.Driver.Preload("Hostel").Preload("HostelRoom").Preload("Student")
+	if hostelId > 0 {
+		query = query.Where("hostel_id = ?", hostelId)
+	}
+	charges := []HostelCharge{}
+	if err := query.Find(&hostelStudents).Error; err != nil {
+		return charges, err
+	}
+
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		for i := range hostelStudents {
+			charge, err := hostelStudents[i].bill(tx, date, dryRun)
+			if err != nil {
+				return err
+			}
+			if charge != nil {
+				charges = append(charges, *charge)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return []HostelCharge{}, err
+	}
+	if !dryRun {
+		for _, charge := range charges {
+			if err := saveStudentBalance(charge.StudentId); err != nil {
+				return charges, err
+			}
+		}
+	}
+	return charges, nil
+}
+
+func (hc *HostelCharge) Name() string {
+	return fmt.Sprintf("Hostel Rent %s - %s", hc.PeriodStart.Format("02 Jan 2006"), hc.PeriodEnd.AddDate(0, 0, -1).Format("02 Jan 2006"))
+}
+
+func (hc *HostelCharge) Create() error {
+	err := db.Driver.Omit("Student").Create(hc).Error
+	return err
+}
+
+func (hc *HostelCharge) Update() error {
+	err := db.Driver.Omit("Student").Save(hc).Error
+	return err
+}
+
+// post records the charge as a hostel debit on the student's ledger through
+// tx. The student balance is left to the caller.
+func (hc *HostelCharge) post(tx *gorm.DB) error {
+	transaction, err := postHostelTransaction(tx, hc.HostelId, hc.StudentId, hc.HostelStudentId, hc.Name(), "debit", hc.Amount)
+	if err != nil {
+		return err
+	}
+	hc.TransactionId = transaction.ID
+	return tx.Omit("Student").Create(hc).Error
+}
+
+// postHostelTransaction posts a cleared hostel transaction through tx. The
+// student balance is left to the caller.
+func postHostelTransaction(tx *gorm.DB, hostelId uint, studentId uint, hostelStudentId uint, name string, transactionType string, amount float64) (*Transaction, error) {
+	hostel := &Hostel{ID: hostelId}
+	transactionCategory, err := hostel.GetTransactionCategory()
+	if err != nil {
+		return nil, err
+	}
+
+	transactionData := map[string]interface{}{"name": name, "student_id": float64(studentId),
+		"hostel_student_id": float64(hostelStudentId), "transaction_category_id": float64(transactionCategory.ID),
+		"is_cleared": true, "transaction_type": transactionType, "amount": amount}
+	transaction := NewTransaction(transactionData, Student{ID: studentId})
+	if err := transaction.create(tx); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func prorate(rate float64, periodStart time.Time, periodEnd time.Time, from time.Time, to time.Time) (float64, bool) {
+	if !from.After(periodStart) && !to.Before(periodEnd) {
+		return rate, false
+	}
+	days := math.Round(to.Sub(from).Hours() / 24)
+	totalDays := math.Round(periodEnd.Sub(periodStart).Hours() / 24)
+	return math.Round(rate * days / totalDays * 100) / 100, true
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
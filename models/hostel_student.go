@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
+	"math"
 	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
 	"time"
 	"gorm.io/gorm"
 	"gopkg.in/validator.v2"
@@ -16,6 +18,7 @@ type HostelStudent struct {
 	ContactNumber  	string `json:"contact_number"  validate:"nonzero"`
 	StudentId				uint `json:"student_id"  validate:"nonzero"`
 	FeeIncluded  		bool `json:"fee_included" gorm:"default:false"`
+	LeftOn 					*time.Time `json:"left_on"`
 	Hostel 					Hostel
 	HostelRoom      HostelRoom
 	Student  				Student
@@ -82,7 +85,7 @@ func (hs *HostelStudent) Find() error {
 
 func (hs *HostelStudent) Create() error {
 	err := db.Driver.Create(hs).Error
-	hs.updateCount()
+	hs.updateCount(db.Driver)
 	if err != nil {
 		return err
 	} else {
@@ -91,13 +94,19 @@ func (hs *HostelStudent) Create() error {
 	return err
 }
 
-func (hs *HostelStudent) updateCount() {
+func (hs *HostelStudent) updateCount(tx *gorm.DB) error {
 	var count int64
-	db.Driver.Model(&HostelStudent{}).Where("hostel_id = ?", hs.HostelId).Count(&count)
-	db.Driver.Model(&Hostel{}).Where("id = ?", hs.HostelId).Update("hostel_students_count", count)
+	if err := tx.Model(&HostelStudent{}).Where("hostel_id = ?", hs.HostelId).Count(&count).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Hostel{}).Where("id = ?", hs.HostelId).Update("hostel_students_count", count).Error; err != nil {
+		return err
+	}
 
-	db.Driver.Model(&HostelStudent{}).Where("hostel_room_id = ?", hs.HostelRoomId).Count(&count)
-	db.Driver.Model(&HostelRoom{}).Where("id = ?", hs.HostelRoomId).Update("hostel_students_count", count)
+	if err := tx.Model(&HostelStudent{}).Where("hostel_room_id = ?", hs.HostelRoomId).Count(&count).Error; err != nil {
+		return err
+	}
+	return tx.Model(&HostelRoom{}).Where("id = ?", hs.HostelRoomId).Update("hostel_students_count", count).Error
 }
 
 func (hs *HostelStudent) Update() error {
@@ -110,33 +119,289 @@ func (hs *HostelStudent) Delete() error {
 	return err
 }
 
+// AddTransaction bills the first period of a new stay. Hostels without a
+// billing cycle charge their rate once, on admission.
 func (hs *HostelStudent) AddTransaction() error {
-	hostel := &Hostel{ID: hs.HostelId}
-	err := hostel.Find()
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		return hs.addTransaction(tx)
+	})
+	if err != nil {
+		return err
+	}
+	return saveStudentBalance(hs.StudentId)
+}
+
+// addTransaction is AddTransaction through tx, leaving the student balance to
+// the caller.
+func (hs *HostelStudent) addTransaction(tx *gorm.DB) error {
+	cycle, rate, err := hs.BillingRate()
 	if err != nil {
 		return err
 	}
+	if cycle != "" {
+		_, err := hs.bill(tx, hs.CreatedAt, false)
+		return err
+	}
+	if hs.FeeIncluded {
+		return nil
+	}
+	_, err = postHostelTransaction(tx, hs.HostelId, hs.StudentId, hs.ID, "New Hostel Adminission", "debit", rate)
+	return err
+}
+
+// BillingRate resolves the rent of the student's room, falling back to the
+// hostel rate, along with the hostel's billing cycle.
+func (hs *HostelStudent) BillingRate() (string, float64, error) {
+	if hs.Hostel.ID != hs.HostelId {
+		hs.Hostel = Hostel{ID: hs.HostelId}
+		if err := hs.Hostel.Find(); err != nil {
+			return "", 0.0, err
+		}
+	}
+	if hs.HostelRoom.ID != hs.HostelRoomId {
+		hs.HostelRoom = HostelRoom{ID: hs.HostelRoomId}
+		if err := hs.HostelRoom.Find(); err != nil {
+			return "", 0.0, err
+		}
+	}
+
+	rate := hs.HostelRoom.Rate
+	if rate <= 0.0 {
+		rate = hs.Hostel.Rate
+	}
+	return hs.Hostel.BillingCycle, rate, nil
+}
+
+// Charge computes the rent for the billing period containing date, prorated
+// for the days the student joined after or left before the period bounds.
+// It returns nil when the student was not in the hostel during the period or
+// the hostel has no billing cycle.
+func (hs *HostelStudent) Charge(date time.Time) (*HostelCharge, error) {
+	cycle, rate, err := hs.BillingRate()
+	if err != nil || cycle == "" {
+		return nil, err
+	}
+
+	periodStart, periodEnd := HostelBillingPeriod(cycle, date)
+	from := periodStart
+	if joinedOn := startOfDay(hs.CreatedAt); joinedOn.After(from) {
+		from = joinedOn
+	}
+	to := periodEnd
+	if hs.LeftOn != nil {
+		if leftOn := startOfDay(*hs.LeftOn).AddDate(0, 0, 1); leftOn.Before(to) {
+			to = leftOn
+		}
+	}
+	if billedTo, err := hs.billedUntil(periodStart); err != nil {
+		return nil, err
+	} else if billedTo.After(from) {
+		from = billedTo
+	}
+	if !from.Before(to) {
+		return nil, nil
+	}
+
+	amount, prorated := prorate(rate, periodStart, periodEnd, from, to)
+	return &HostelCharge{HostelStudentId: hs.ID, StudentId: hs.StudentId, HostelId: hs.HostelId,
+		HostelRoomId: hs.HostelRoomId, BillingCycle: cycle, PeriodStart: periodStart, PeriodEnd: periodEnd,
+		Rate: rate, Amount: amount, Prorated: prorated, Student: hs.Student}, nil
+}
+
+func (hs *HostelStudent) GetCharge(periodStart time.Time) (*HostelCharge, error) {
+	return hs.getCharge(db.Driver, periodStart)
+}
+
+func (hs *HostelStudent) getCharge(tx *gorm.DB, periodStart time.Time) (*HostelCharge, error) {
+	var charges []HostelCharge
+	err := tx.Where("hostel_student_id = ? and period_start = ?", hs.ID, periodStart).Limit(1).Find(&charges).Error
+	if err != nil || len(charges) == 0 {
+		return nil, err
+	}
+	return &charges[0], nil
+}
+
+// billedUntil returns the end of the latest charge for a period before
+// periodStart, so a change of billing cycle never bills the same days twice.
+func (hs *HostelStudent) billedUntil(periodStart time.Time) (time.Time, error) {
+	var charges []HostelCharge
+	err := db.Driver.Where("hostel_student_id = ? and period_start < ?", hs.ID, periodStart).
+		Order("period_end desc").Limit(1).Find(&charges).Error
+	if err != nil || len(charges) == 0 {
+		return time.Time{}, err
+	}
+	return charges[0].PeriodEnd, nil
+}
+
+// Bill posts the rent for the period containing date unless the student's fee
+// is included or the period has already been billed.
+func (hs *HostelStudent) Bill(date time.Time, dryRun bool) (*HostelCharge, error) {
+	var charge *HostelCharge
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		var err error
+		charge, err = hs.bill(tx, date, dryRun)
+		return err
+	})
+	if err != nil || charge == nil || dryRun {
+		return charge, err
+	}
+	return charge, saveStudentBalance(hs.StudentId)
+}
+
+// bill is Bill through tx, leaving the student balance to the caller.
+func (hs *HostelStudent) bill(tx *gorm.DB, date time.Time, dryRun bool) (*HostelCharge, error) {
+	if hs.FeeIncluded {
+		return nil, nil
+	}
+
+	charge, err := hs.Charge(date)
+	if err != nil || charge == nil || charge.Amount <= 0.0 {
+		return nil, err
+	}
+
+	billed, err := hs.GetCharge(charge.PeriodStart)
+	if err != nil || billed != nil {
+		return nil, err
+	}
 
-	transactionCategory, err := hostel.GetTransactionCategory()
+	if dryRun {
+		return charge, nil
+	}
+	return charge, charge.post(tx)
+}
+
+// Leave vacates the student on date. The final period is billed up to that
+// day, crediting back the unused part if it was already billed in full.
+func (hs *HostelStudent) Leave(date time.Time) error {
+	hs.LeftOn = &date
+	charge, err := hs.Charge(date)
 	if err != nil {
-	 	return err
+		return err
 	}
-	amount := 0.0
-	if !hs.FeeIncluded {
-		amount = hostel.Rate
+	var billed *HostelCharge
+	if charge != nil && !hs.FeeIncluded {
+		if billed, err = hs.GetCharge(charge.PeriodStart); err != nil {
+			return err
+		}
 	}
-	transactionData := map[string]interface{}{"name": "New Hostel Adminission", "student_id": float64(hs.StudentId), 
-		"hostel_student_id": float64(hs.ID), "transaction_category_id": float64(transactionCategory.ID),
-		"is_cleared": true, "transaction_type": "debit", "amount": amount}
 
-	student := &Student{ID: hs.StudentId}
-	err = hostel.Find()
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		if charge != nil && !hs.FeeIncluded {
+			if billed == nil {
+				if err := charge.post(tx); err != nil {
+					return err
+				}
+			} else if billed.Amount > charge.Amount {
+				_, err := postHostelTransaction(tx, hs.HostelId, hs.StudentId, hs.ID, "Hostel Rent Adjustment", "credit", billed.Amount - charge.Amount)
+				if err != nil {
+					return err
+				}
+				billed.Amount = charge.Amount
+				billed.Prorated = true
+				if err := tx.Omit("Student").Save(billed).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Model(hs).Update("left_on", hs.LeftOn).Error; err != nil {
+			return err
+		}
+		return tx.Delete(hs).Error
+	})
 	if err != nil {
 		return err
 	}
+	hs.updateCount(db.Driver)
+	return saveStudentBalance(hs.StudentId)
+}
 
-	transaction := NewTransaction(transactionData, *student)
-	err = transaction.Create()
-	
-	return err
+// Transfer moves the student to another hostel room from effectiveOn. The
+// period in progress is billed first and then adjusted by the prorated
+// difference between the old and new rate for the remaining days, all in one
+// transaction. Both hostels need a billing cycle to prorate over, unless the
+// student's fee is included.
+func (hs *HostelStudent) Transfer(h *Hostel, hr *HostelRoom, effectiveOn time.Time, userId int, reason string) (*HostelTransfer, error) {
+	if hr.HostelID != h.ID {
+		return nil, swapErr.ErrRoomNotInHostel
+	}
+	if hr.ID == hs.HostelRoomId {
+		return nil, swapErr.ErrAlreadyInRoom
+	}
+	if hr.NoOfStudents > 0 && hr.HostelStudentsCount >= int64(hr.NoOfStudents) {
+		return nil, swapErr.ErrRoomFull
+	}
+
+	fromCycle, fromRate, err := hs.BillingRate()
+	if err != nil {
+		return nil, err
+	}
+	if !hs.FeeIncluded && (fromCycle == "" || h.BillingCycle == "") {
+		return nil, swapErr.ErrNoBillingCycle
+	}
+	periodStart, periodEnd := HostelBillingPeriod(fromCycle, effectiveOn)
+
+	transfer := &HostelTransfer{HostelStudentId: hs.ID, StudentId: hs.StudentId, FromHostelId: hs.HostelId,
+		FromHostelRoomId: hs.HostelRoomId, FromRate: fromRate, ToHostelId: h.ID, ToHostelRoomId: hr.ID,
+		EffectiveOn: startOfDay(effectiveOn), UserID: userId, Reason: reason}
+	previous := *hs
+
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		if _, err := hs.bill(tx, effectiveOn, false); err != nil {
+			return err
+		}
+		billed, err := hs.getCharge(tx, periodStart)
+		if err != nil {
+			return err
+		}
+
+		hs.HostelId, hs.Hostel = h.ID, *h
+		hs.HostelRoomId, hs.HostelRoom = hr.ID, *hr
+		toCycle, toRate, err := hs.BillingRate()
+		if err != nil {
+			return err
+		}
+		transfer.ToRate = toRate
+
+		if billed != nil && !hs.FeeIncluded && transfer.EffectiveOn.Before(periodEnd) {
+			from := transfer.EffectiveOn
+			if from.Before(periodStart) {
+				from = periodStart
+			}
+			cycleRate := toRate * float64(billingCycleMonths(fromCycle)) / float64(billingCycleMonths(toCycle))
+			fromAmount, _ := prorate(fromRate, periodStart, periodEnd, from, periodEnd)
+			toAmount, _ := prorate(cycleRate, periodStart, periodEnd, from, periodEnd)
+			transfer.Amount = math.Round((toAmount - fromAmount) * 100) / 100
+		}
+
+		err = tx.Model(hs).Updates(map[string]interface{}{"hostel_id": hs.HostelId, "hostel_room_id": hs.HostelRoomId}).Error
+		if err != nil {
+			return err
+		}
+
+		if transfer.Amount != 0.0 {
+			transactionType, amount := "debit", transfer.Amount
+			if amount < 0.0 {
+				transactionType, amount = "credit", -amount
+			}
+			transaction, err := postHostelTransaction(tx, hs.HostelId, hs.StudentId, hs.ID, "Hostel Transfer Adjustment", transactionType, amount)
+			if err != nil {
+				return err
+			}
+			transfer.TransactionId = transaction.ID
+			billed.Amount = billed.Amount + transfer.Amount
+			billed.Prorated = true
+			if err := tx.Omit("Student").Save(billed).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("FromHostel", "FromHostelRoom", "ToHostel", "ToHostelRoom").Create(transfer).Error
+	})
+	if err != nil {
+		*hs = previous
+		return nil, err
+	}
+
+	previous.updateCount(db.Driver)
+	hs.updateCount(db.Driver)
+	return transfer, saveStudentBalance(hs.StudentId)
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"swapnil-ex/models/db"
+	"time"
+	"gorm.io/gorm"
+)
+
+type HostelTransfer struct {
+	ID            		uint `json:"id"`
+	HostelStudentId 	uint `json:"hostel_student_id"`
+	StudentId					uint `json:"student_id" gorm:"index"`
+	FromHostelId 			uint `json:"from_hostel_id"`
+	FromHostelRoomId 	uint `json:"from_hostel_room_id"`
+	FromRate 					float64 `json:"from_rate"`
+	ToHostelId 				uint `json:"to_hostel_id"`
+	ToHostelRoomId 		uint `json:"to_hostel_room_id"`
+	ToRate 						float64 `json:"to_rate"`
+	EffectiveOn 			time.Time `json:"effective_on"`
+	Amount 						float64 `json:"amount"`
+	TransactionId 		uint `json:"transaction_id"`
+	Reason 						string `json:"reason"`
+	UserID						int `json:"user_id"`
+	FromHostel 				Hostel `json:"from_hostel"`
+	FromHostelRoom 		HostelRoom `json:"from_hostel_room"`
+	ToHostel 					Hostel `json:"to_hostel"`
+	ToHostelRoom 			HostelRoom `json:"to_hostel_room"`
+	CreatedAt 				time.Time
+	UpdatedAt 				time.Time
+  DeletedAt 				gorm.DeletedAt `gorm:"index"`
+}
+
+func migrateHostelTransfer() {
+	fmt.Println("migrating hostel transfer..")
+	err := db.Driver.AutoMigrate(&HostelTransfer{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func (ht *HostelTransfer) All(studentId uint) ([]HostelTransfer, error) {
+	var hostelTransfers []HostelTransfer
+	err := db.Driver.Preload("FromHostel").Preload("FromHostelRoom").Preload("ToHostel").Preload("ToHostelRoom").
+		Where("student_id = ?", studentId).Order("effective_on desc, id desc").Find(&hostelTransfers).Error
+	return hostelTransfers, err
+}
+
+func (ht *HostelTransfer) Find() error {
+	err := db.Driver.First(ht, "ID = ?", ht.ID).Error
+	return err
+}
+
+func (ht *HostelTransfer) Create() error {
+	err := db.Driver.Omit("FromHostel", "FromHostelRoom", "ToHostel", "ToHostelRoom").Create(ht).Error
+	return err
+}
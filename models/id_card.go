@@ -0,0 +1,224 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"swapnil-ex/constants"
+	"swapnil-ex/idcard"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"sync"
+	"time"
+)
+
+var idCardKey []byte
+var idCardKeyLock sync.Mutex
+
+// idCardSecret signs ID card tokens: ID_CARD_SECRET when set, otherwise a
+// random key made on first use and kept in id_card.key so printed cards stay
+// valid across restarts. A key that cannot be read or saved is an error, as
+// cards signed with it would stop verifying after a restart.
+func idCardSecret() ([]byte, error) {
+	idCardKeyLock.Lock()
+	defer idCardKeyLock.Unlock()
+	if idCardKey != nil {
+		return idCardKey, nil
+	}
+	if secret := os.Getenv("ID_CARD_SECRET"); secret != "" {
+		idCardKey = []byte(secret)
+		return idCardKey, nil
+	}
+	key, err := os.ReadFile(constants.ID_CARD_KEY_FILE)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(constants.ID_CARD_KEY_FILE, key, 0600); err != nil {
+			return nil, err
+		}
+	}
+	idCardKey = key
+	return idCardKey, nil
+}
+
+// IdCardToken is the signed token printed in the QR code of a card: the
+// student id and issue time in base 36 and a truncated HMAC of both.
+func IdCardToken(studentId uint) (string, error) {
+	payload := strconv.FormatUint(uint64(studentId), 36) + "." + strconv.FormatInt(time.Now().Unix(), 36)
+	signature, err := idCardSignature(payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + signature, nil
+}
+
+func idCardSignature(payload string) (string, error) {
+	secret, err := idCardSecret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// VerifyIdCardToken checks the signature of a scanned token, which may also
+// be the verify URL carrying it, and returns the student id and issue time.
+func VerifyIdCardToken(token string) (uint, time.Time, error) {
+	token = strings.TrimSpace(token)
+	if strings.Contains(token, "token=") {
+		if u, err := url.Parse(token); err == nil {
+			token = u.Query().Get("token")
+		}
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, swapErr.ErrInvalidIdCard
+	}
+	signature, err := idCardSignature(parts[0] + "." + parts[1])
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signature)) {
+		return 0, time.Time{}, swapErr.ErrInvalidIdCard
+	}
+	studentId, err := strconv.ParseUint(parts[0], 36, 64)
+	if err != nil {
+		return 0, time.Time{}, swapErr.ErrInvalidIdCard
+	}
+	issued, err := strconv.ParseInt(parts[1], 36, 64)
+	if err != nil {
+		return 0, time.Time{}, swapErr.ErrInvalidIdCard
+	}
+	return uint(studentId), time.Unix(issued, 0), nil
+}
+
+// CheckIdCard tells whether a card issued at issuedAt still holds: the
+// student must not have left and the card must be under
+// ID_CARD_VALIDITY_DAYS old.
+func (s *Student) CheckIdCard(issuedAt time.Time) error {
+	if s.Status == StudentDropped || s.Status == StudentPassedOut || s.Status == StudentTransferred {
+		return swapErr.ErrStudentLeft
+	}
+	if time.Since(issuedAt) > constants.ID_CARD_VALIDITY_DAYS*24*time.Hour {
+		return swapErr.ErrIdCardExpired
+	}
+	return nil
+}
+
+// IdCardDetails fills the card of the student from their current class and
+// hostel, without the photo. The emergency contact falls back to the primary
+// guardian and then the student's contact number. The QR code holds a fresh
+// token, inside ID_CARD_VERIFY_URL when that is set.
+func (s *Student) IdCardDetails() (idcard.Card, error) {
+	title := os.Getenv("SCHOOL_NAME")
+	if title == "" {
+		title = "STUDENT ID CARD"
+	}
+	card := idcard.Card{Title: title, StudentId: s.ID, Name: strings.Join(strings.Fields(s.FirstName + " " + s.MiddleName + " " + s.LastName), " "),
+		RollNumber: s.RollNumber, BloodGroup: s.BloodGroup, EmergencyContact: s.EmergencyContact}
+
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Preload("BatchStandard.Batch").Preload("BatchStandard.Standard").Where("student_id = ?", s.ID).
+		Order("id desc").Limit(1).Find(&batchStandardStudents).Error
+	if err != nil {
+		return card, err
+	}
+	if len(batchStandardStudents) > 0 {
+		card.Class = batchStandardName(batchStandardStudents[0].BatchStandard)
+		if batchStandardStudents[0].RollNumber != "" {
+			card.RollNumber = batchStandardStudents[0].RollNumber
+		}
+	}
+
+	var hostelStudents []HostelStudent
+	err = db.Driver.Preload("Hostel").Preload("HostelRoom").Where("student_id = ? and left_on is null", s.ID).
+		Limit(1).Find(&hostelStudents).Error
+	if err != nil {
+		return card, err
+	}
+	if len(hostelStudents) > 0 {
+		card.Hostel = hostelStudents[0].Hostel.Name + ", room " + hostelStudents[0].HostelRoom.Name
+	}
+
+	if card.EmergencyContact == "" {
+		if guardians, err := (&Guardian{}).All(s.ID); err == nil && len(guardians) > 0 {
+			card.EmergencyContact = guardians[0].Phone
+		}
+	}
+	if card.EmergencyContact == "" {
+		card.EmergencyContact = s.ContactNumber
+	}
+
+	card.QR, err = IdCardToken(s.ID)
+	if err != nil {
+		return card, err
+	}
+	if verifyURL := os.Getenv("ID_CARD_VERIFY_URL"); verifyURL != "" {
+		card.QR = verifyURL + "?token=" + card.QR
+	}
+	return card, nil
+}
+
+// IdCard is the card of the student with their latest photo, if any.
+func (s *Student) IdCard() (idcard.Card, error) {
+	card, err := s.IdCardDetails()
+	if err != nil {
+		return card, err
+	}
+	var photos []StudentDocument
+	err = db.Driver.Where("student_id = ? and category = ?", s.ID, "photo").Order("id desc").Limit(1).Find(&photos).Error
+	if err != nil || len(photos) == 0 {
+		return card, err
+	}
+	file, err := photos[0].Open()
+	if err != nil {
+		// print the card without the photo rather than not at all
+		fmt.Println("sd.Open(IdCard)", err)
+		return card, nil
+	}
+	defer file.Close()
+	card.Photo, err = io.ReadAll(io.LimitReader(file, constants.DOCUMENT_MAX_SIZE))
+	if err != nil {
+		fmt.Println("io.ReadAll(IdCard)", err)
+		card.Photo = nil
+	}
+	return card, nil
+}
+
+// IdCards returns the cards of the students of the class in roll number order.
+func (bs *BatchStandard) IdCards() ([]idcard.Card, error) {
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Preload("Student").Where("batch_standard_id = ?", bs.ID).Order("roll_sequence").Order("id").
+		Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, err
+	}
+	cards := []idcard.Card{}
+	for _, bss := range batchStandardStudents {
+		if bss.Student.ID == 0 {
+			// the student was deleted
+			continue
+		}
+		card, err := bss.Student.IdCard()
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
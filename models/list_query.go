@@ -0,0 +1,210 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"gorm.io/gorm"
+)
+
+const MAX_PAGE_SIZE = 100
+
+// ListFields is the allow-list of a list endpoint: the fields that can be
+// filtered, sorted and selected, mapped to their column. A field with no
+// column, like a preloaded association, can only be selected.
+type ListFields map[string]string
+
+type ListFilter struct {
+	Field 	string
+	Column 	string
+	Op 			string
+	Value 	string
+}
+
+// ListQuery is a parsed list request: filter[field][op]=value, sort,
+// page, page_size and fields. A PageSize of 0 lists everything.
+type ListQuery struct {
+	Filters 	[]ListFilter
+	Sort 			[]string
+	Fields 		[]string
+	Page 			int
+	PageSize 	int
+}
+
+var listFilterParam = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+var listFilterOps = map[string]string{"eq": "= ?", "ne": "<> ?", "gt": "> ?", "gte": ">= ?", "lt": "< ?", "lte": "<= ?",
+	"like": "LIKE ?", "in": "IN (?)", "null": "IS NULL"}
+
+// ParseListQuery reads the list params of values against fields. sort falls
+// back to defaultSort and page_size to defaultPageSize.
+func ParseListQuery(values url.Values, fields ListFields, defaultSort string, defaultPageSize int) (*ListQuery, error) {
+	lq := &ListQuery{Page: 1, PageSize: defaultPageSize}
+
+	for param, paramValues := range values {
+		match := listFilterParam.FindStringSubmatch(param)
+		if match == nil {
+			continue
+		}
+		column, ok := fields[match[1]]
+		if !ok || column == "" {
+			return nil, fmt.Errorf("Cannot filter by %s", match[1])
+		}
+		op := match[2]
+		if op == "" {
+			op = "eq"
+		}
+		if _, ok := listFilterOps[op]; !ok {
+			return nil, fmt.Errorf("Unknown filter operator %s", op)
+		}
+		for _, value := range paramValues {
+			lq.Filters = append(lq.Filters, ListFilter{Field: match[1], Column: column, Op: op, Value: value})
+		}
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = defaultSort
+	}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "asc"
+		if strings.HasPrefix(field, "-") {
+			field, direction = field[1:], "desc"
+		}
+		column, ok := fields[field]
+		if !ok || column == "" {
+			return nil, fmt.Errorf("Cannot sort by %s", field)
+		}
+		lq.Sort = append(lq.Sort, column + " " + direction)
+	}
+
+	if selected := values.Get("fields"); selected != "" {
+		for _, field := range strings.Split(selected, ",") {
+			field = strings.TrimSpace(field)
+			if _, ok := fields[field]; !ok {
+				return nil, fmt.Errorf("Unknown field %s", field)
+			}
+			lq.Fields = append(lq.Fields, field)
+		}
+	}
+
+	if page := values.Get("page"); page != "" {
+		if number, err := strconv.Atoi(page); err == nil && number > 0 {
+			lq.Page = number
+		}
+	}
+	if pageSize := values.Get("page_size"); pageSize != "" {
+		number, err := strconv.Atoi(pageSize)
+		if err != nil || number < 1 || number > MAX_PAGE_SIZE {
+			return nil, fmt.Errorf("page_size must be between 1 and %d", MAX_PAGE_SIZE)
+		}
+		lq.PageSize = number
+	}
+	return lq, nil
+}
+
+// Filter applies the filters only, for counts.
+func (lq *ListQuery) Filter(tx *gorm.DB) *gorm.DB {
+	for _, filter := range lq.Filters {
+		clause := filter.Column + " " + listFilterOps[filter.Op]
+		switch filter.Op {
+		case "null":
+			if isNull, _ := strconv.ParseBool(filter.Value); !isNull {
+				clause = filter.Column + " IS NOT NULL"
+			}
+			tx = tx.Where(clause)
+		case "in":
+			values := []interface{}{}
+			for _, value := range strings.Split(filter.Value, ",") {
+				values = append(values, listFilterValue(strings.TrimSpace(value)))
+			}
+			tx = tx.Where(clause, values)
+		case "like":
+			tx = tx.Where(clause, "%" + filter.Value + "%")
+		default:
+			tx = tx.Where(clause, listFilterValue(filter.Value))
+		}
+	}
+	return tx
+}
+
+func (lq *ListQuery) Order(tx *gorm.DB) *gorm.DB {
+	for _, sort := range lq.Sort {
+		tx = tx.Order(sort)
+	}
+	return tx
+}
+
+func (lq *ListQuery) Paginate(tx *gorm.DB) *gorm.DB {
+	if lq.PageSize == 0 {
+		return tx
+	}
+	return tx.Limit(lq.PageSize).Offset((lq.Page - 1) * lq.PageSize)
+}
+
+// Scope filters, sorts and paginates a list query.
+func (lq *ListQuery) Scope(tx *gorm.DB) *gorm.DB {
+	return lq.Paginate(lq.Order(lq.Filter(tx)))
+}
+
+// Pick keeps only the selected fields of each record of list, which is
+// returned as is when no fields were asked for. A selected field that is
+// not a key of the record is taken from its custom_fields, so custom fields
+// can be picked by their key.
+func (lq *ListQuery) Pick(list interface{}) (interface{}, error) {
+	if len(lq.Fields) == 0 {
+		return list, nil
+	}
+	body, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(body, &records); err != nil {
+		return nil, err
+	}
+
+	selected := map[string]bool{}
+	for _, field := range lq.Fields {
+		selected[listFieldKey(field)] = true
+	}
+	for _, record := range records {
+		if custom, ok := record["custom_fields"].(map[string]interface{}); ok {
+			for _, field := range lq.Fields {
+				if _, ok := record[field]; !ok {
+					if value, ok := custom[field]; ok {
+						record[field] = value
+					}
+				}
+			}
+		}
+		for key := range record {
+			if !selected[listFieldKey(key)] {
+				delete(record, key)
+			}
+		}
+	}
+	return records, nil
+}
+
+// listFieldKey matches created_at with the untagged CreatedAt.
+func listFieldKey(field string) string {
+	return strings.ToLower(strings.ReplaceAll(field, "_", ""))
+}
+
+func listFilterValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
@@ -17,4 +17,26 @@ func init() {
 	migrateTransaction()
 	migrateCheque()
 	migrateStudentAccount()
+	migrateHostelCharge()
+	migrateHostelTransfer()
+	migrateFiscalYear()
+	migratePosItem()
+	migratePosSale()
+	migrateDeposit()
+	migrateFeeRevision()
+	migrateWriteOff()
+	migrateStudentStatusChange()
+	migrateGuardian()
+	migrateStudentDocument()
+	migrateAadhaarAccessLog()
+	migrateStudentMerge()
+	migrateStudentSearch()
+	migratePromotion()
+	migrateBatchStandardTransfer()
+	migrateStudentNote()
+	migrateTrashLog()
+	migrateCertificate()
+	migrateCustomField()
+	migrateTag()
+	migrateStudentSegment()
 }
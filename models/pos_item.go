@@ -0,0 +1,131 @@
+package models
+
+import (
+	"fmt"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+type PosItem struct {
+	ID            	uint `json:"id"`
+	Name     				string `json:"name" validate:"nonzero"`
+	Category 				string `json:"category" validate:"nonzero"`
+	Price 					float64 `json:"price" validate:"nonzero"`
+	Stock 					int `json:"stock" gorm:"default:0"`
+	LowStockLevel 	int `json:"low_stock_level" gorm:"default:0"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+func migratePosItem() {
+	fmt.Println("migrating pos item..")
+	err := db.Driver.AutoMigrate(&PosItem{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func NewPosItem(posItemData map[string]interface{}) *PosItem {
+	posItem := &PosItem{}
+	posItem.Assign(posItemData)
+	return posItem
+}
+
+// Validate also requires a price above zero, as a sale of the item debits
+// the student's wallet by it.
+func (pi *PosItem) Validate() error {
+	errs := validator.ErrorMap{}
+	if err := validator.Validate(pi); err != nil {
+		errs = err.(validator.ErrorMap)
+	}
+	if pi.Price < 0.0 {
+		errs["Price"] = append(errs["Price"], validator.TextErr{Err: swapErr.ErrNotPositive})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (pi *PosItem) Assign(posItemData map[string]interface{}) {
+	if name, ok := posItemData["name"]; ok {
+		pi.Name = name.(string)
+	}
+
+	if category, ok := posItemData["category"]; ok {
+		pi.Category = category.(string)
+	}
+
+	if price, ok := posItemData["price"]; ok {
+		pi.Price = price.(float64)
+	}
+
+	if stock, ok := posItemData["stock"]; ok {
+		pi.Stock = int(stock.(float64))
+	}
+
+	if lowStockLevel, ok := posItemData["low_stock_level"]; ok {
+		pi.LowStockLevel = int(lowStockLevel.(float64))
+	}
+}
+
+var PosItemListFields = ListFields{"id": "id", "name": "name", "category": "category", "price": "price", "stock": "stock",
+	"low_stock_level": "low_stock_level", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (pi *PosItem) All(lq *ListQuery, category string) ([]PosItem, error) {
+	var posItems []PosItem
+	query := db.Driver.Scopes(lq.Scope)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	err := query.Find(&posItems).Error
+	return posItems, err
+}
+
+func (pi *PosItem) Count(lq *ListQuery, category string) (int64, error) {
+	var count int64
+	query := db.Driver.Model(&PosItem{}).Scopes(lq.Filter)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (pi *PosItem) LowStock() ([]PosItem, error) {
+	var posItems []PosItem
+	err := db.Driver.Where("stock <= low_stock_level").Order("stock").Find(&posItems).Error
+	return posItems, err
+}
+
+func (pi *PosItem) Find() error {
+	err := db.Driver.First(pi, "ID = ?", pi.ID).Error
+	return err
+}
+
+func (pi *PosItem) Create() error {
+	err := db.Driver.Create(pi).Error
+	return err
+}
+
+func (pi *PosItem) Update() error {
+	err := db.Driver.Save(pi).Error
+	return err
+}
+
+func (pi *PosItem) Delete() error {
+	err := db.Driver.Delete(pi).Error
+	return err
+}
+
+func (pi *PosItem) Restock(quantity int) error {
+	err := db.Driver.Model(pi).Update("stock", gorm.Expr("stock + ?", quantity)).Error
+	if err != nil {
+		return err
+	}
+	return pi.Find()
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+)
+
+type PosSale struct {
+	ID            		uint `json:"id"`
+	StudentId					uint `json:"student_id" validate:"nonzero"`
+	StudentAccountId 	uint `json:"student_account_id"`
+	Total 						float64 `json:"total"`
+	UserID						int `json:"user_id"`
+	PosSaleLines 			[]PosSaleLine `json:"lines"`
+	Student 					Student `json:"student"`
+	CreatedAt 				time.Time
+	UpdatedAt 				time.Time
+  DeletedAt 				gorm.DeletedAt `gorm:"index"`
+}
+
+type PosSaleLine struct {
+	ID            	uint `json:"id"`
+	PosSaleId 			uint `json:"pos_sale_id" gorm:"index"`
+	PosItemId 			uint `json:"pos_item_id"`
+	Name     				string `json:"name"`
+	Quantity 				int `json:"quantity"`
+	Price 					float64 `json:"price"`
+	Amount 					float64 `json:"amount"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+type PosItemSummary struct {
+	PosItemId 	uint `json:"pos_item_id"`
+	Name     		string `json:"name"`
+	Quantity 		int `json:"quantity"`
+	Amount 			float64 `json:"amount"`
+}
+
+func migratePosSale() {
+	fmt.Println("migrating pos sale..")
+	err := db.Driver.AutoMigrate(&PosSale{}, &PosSaleLine{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// NewPosSale builds the sale lines from the requested items, pricing each
+// line from the catalogue.
+func NewPosSale(posSaleData map[string]interface{}, student *Student) (*PosSale, error) {
+	posSale := &PosSale{StudentId: student.ID, Student: *student}
+	items, ok := posSaleData["items"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, swapErr.ErrBadData
+	}
+
+	for _, item := range items {
+		itemData, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, swapErr.ErrBadData
+		}
+		posItemId, ok := itemData["pos_item_id"].(float64)
+		if !ok {
+			return nil, swapErr.ErrBadData
+		}
+		quantity, ok := itemData["quantity"].(float64)
+		if !ok || quantity < 1 || quantity != math.Trunc(quantity) {
+			return nil, swapErr.ErrBadData
+		}
+
+		posItem := &PosItem{ID: uint(posItemId)}
+		if err := posItem.Find(); err != nil {
+			return nil, err
+		}
+		line := PosSaleLine{PosItemId: posItem.ID, Name: posItem.Name, Quantity: int(quantity), Price: posItem.Price}
+		line.Amount = line.Price * float64(line.Quantity)
+		posSale.PosSaleLines = append(posSale.PosSaleLines, line)
+		posSale.Total = posSale.Total + line.Amount
+	}
+	return posSale, nil
+}
+
+func (ps *PosSale) All(studentId uint) ([]PosSale, error) {
+	var posSales []PosSale
+	err := db.Driver.Preload("PosSaleLines").Where("student_id = ?", studentId).Order("id desc").Find(&posSales).Error
+	return posSales, err
+}
+
+func (ps *PosSale) Find() error {
+	err := db.Driver.Preload("PosSaleLines").First(ps, "ID = ?", ps.ID).Error
+	return err
+}
+
+// Create takes the items out of stock and debits the total from the
+// student's wallet in one database transaction, checking the wallet balance
+// inside it so concurrent sales cannot overdraw it.
+func (ps *PosSale) Create() error {
+	student := &Student{ID: ps.StudentId}
+	if err := student.Find(); err != nil {
+		return err
+	}
+
+	studentAccount := &StudentAccount{StudentId: ps.StudentId, TransactionType: "debit", Amount: ps.Total,
+		UserID: uint(ps.UserID), Reason: "Canteen/Stationery sale"}
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		balance, err := lockedStudentAccountBalance(tx, ps.StudentId)
+		if err != nil {
+			return err
+		}
+		if balance < ps.Total {
+			return swapErr.ErrInsufficientBalance
+		}
+		for _, line := range ps.PosSaleLines {
+			result := tx.Model(&PosItem{}).Where("id = ? and stock >= ?", line.PosItemId, line.Quantity).
+				Update("stock", gorm.Expr("stock - ?", line.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return swapErr.ErrOutOfStock
+			}
+		}
+
+		if err := tx.Omit("Student").Create(studentAccount).Error; err != nil {
+			return err
+		}
+		ps.StudentAccountId = studentAccount.ID
+		if err := tx.Omit("Student").Create(ps).Error; err != nil {
+			return err
+		}
+		return tx.Model(studentAccount).Update("pos_sale_id", ps.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := student.SaveStudentAccountBalance(); err != nil {
+		return err
+	}
+	studentAccount.Balance = student.StudentAccountBalance
+	ps.Student = *student
+	return db.Driver.Model(studentAccount).Update("balance", studentAccount.Balance).Error
+}
+
+// DailySummary totals the sales made on the day of date, item by item.
+func (ps *PosSale) DailySummary(date time.Time) ([]PosItemSummary, int64, float64, error) {
+	from := startOfDay(date)
+	to := from.AddDate(0, 0, 1)
+	summaries := []PosItemSummary{}
+	err := db.Driver.Model(&PosSaleLine{}).Select("pos_item_id, name, sum(quantity) as quantity, sum(amount) as amount").
+		Where("created_at >= ? and created_at < ?", from, to).Group("pos_item_id, name").Order("amount desc").
+		Scan(&summaries).Error
+	if err != nil {
+		return summaries, 0, 0.0, err
+	}
+
+	var count int64
+	var total float64
+	query := db.Driver.Model(&PosSale{}).Where("created_at >= ? and created_at < ?", from, to)
+	if err := query.Count(&count).Error; err != nil {
+		return summaries, 0, 0.0, err
+	}
+	for _, summary := range summaries {
+		total = total + summary.Amount
+	}
+	return summaries, count, total, nil
+}
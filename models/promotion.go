@@ -0,0 +1,345 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+)
+
+// PromotionMapping moves the students of one batch standard to another,
+// typically the next standard of the next batch.
+type PromotionMapping struct {
+	FromBatchStandardId 	uint `json:"from_batch_standard_id"`
+	ToBatchStandardId 		uint `json:"to_batch_standard_id"`
+	from 									*BatchStandard
+	to 										*BatchStandard
+	category 							*TransactionCategory
+	fromCategory 					*TransactionCategory
+	fee 									float64
+}
+
+type PromotionOptions struct {
+	Mappings 						[]PromotionMapping
+	ExcludeStudentIds 	[]uint
+	CarryForward 				bool
+	Reason 							string
+	DryRun 							bool
+}
+
+type PromotionStudent struct {
+	StudentId 								uint `json:"student_id"`
+	Name 											string `json:"name"`
+	FromBatchStandardId 			uint `json:"from_batch_standard_id"`
+	ToBatchStandardId 				uint `json:"to_batch_standard_id"`
+	BatchStandardStudentId 		uint `json:"batch_standard_student_id,omitempty"`
+	RollNumber 								string `json:"roll_number,omitempty"`
+	Balance 									float64 `json:"balance"`
+	Fee 											float64 `json:"fee"`
+	CarriedForward 						float64 `json:"carried_forward"`
+	Result 										string `json:"result"`
+	Reason 										string `json:"reason,omitempty"`
+	bss 											BatchStandardStudent
+	student 									Student
+}
+
+type PromotionClassSummary struct {
+	FromBatchStandardId 	uint `json:"from_batch_standard_id"`
+	ToBatchStandardId 		uint `json:"to_batch_standard_id"`
+	Promoted 							int `json:"promoted"`
+	Excluded 							int `json:"excluded"`
+	Skipped 							int `json:"skipped"`
+	FeesBilled 						float64 `json:"fees_billed"`
+	DuesCarried 					float64 `json:"dues_carried"`
+}
+
+type PromotionReport struct {
+	Classes 			[]PromotionClassSummary `json:"classes"`
+	Students 			[]PromotionStudent `json:"students"`
+	Promoted 			int `json:"promoted"`
+	Excluded 			int `json:"excluded"`
+	Skipped 			int `json:"skipped"`
+	FeesBilled 		float64 `json:"fees_billed"`
+	DuesCarried 	float64 `json:"dues_carried"`
+	DryRun 				bool `json:"dry_run"`
+}
+
+// Promotion keeps the report of a committed year-end promotion.
+type Promotion struct {
+	ID            	uint `json:"id"`
+	CarryForward 		bool `json:"carry_forward"`
+	Reason 					string `json:"reason"`
+	Promoted 				int `json:"promoted"`
+	Excluded 				int `json:"excluded"`
+	Skipped 				int `json:"skipped"`
+	FeesBilled 			float64 `json:"fees_billed"`
+	DuesCarried 		float64 `json:"dues_carried"`
+	Report 					string `json:"-"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+const (
+	PromotionPromoted = "promoted"
+	PromotionExcluded = "excluded"
+	PromotionSkipped = "skipped"
+)
+
+func migratePromotion() {
+	fmt.Println("migrating promotion..")
+	err := db.Driver.AutoMigrate(&Promotion{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func (p *Promotion) All() ([]Promotion, error) {
+	var promotions []Promotion
+	err := db.Driver.Order("id desc").Find(&promotions).Error
+	return promotions, err
+}
+
+func (p *Promotion) Find() error {
+	err := db.Driver.First(p, "ID = ?", p.ID).Error
+	return err
+}
+
+func (p *Promotion) GetReport() (*PromotionReport, error) {
+	report := &PromotionReport{}
+	err := json.Unmarshal([]byte(p.Report), report)
+	return report, err
+}
+
+// PromoteStudents moves the students of each source class to its target
+// class. Excluded students and those no longer studying stay behind, as do
+// students with dues unless CarryForward moves the dues onto the new
+// enrollment. Promoted students are billed the fee of the new class and
+// marked Promoted, all in one transaction; a dry run only reports.
+func PromoteStudents(options PromotionOptions, userId int) (*PromotionReport, *Promotion, error) {
+	report := &PromotionReport{Classes: []PromotionClassSummary{}, Students: []PromotionStudent{}, DryRun: options.DryRun}
+	if err := checkPromotionMappings(options.Mappings); err != nil {
+		return nil, nil, err
+	}
+
+	excluded := map[uint]bool{}
+	for _, studentId := range options.ExcludeStudentIds {
+		excluded[studentId] = true
+	}
+	for i := range options.Mappings {
+		mapping := &options.Mappings[i]
+		summary := PromotionClassSummary{FromBatchStandardId: mapping.FromBatchStandardId, ToBatchStandardId: mapping.ToBatchStandardId}
+		students, err := planPromotion(mapping, excluded, options.CarryForward)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, student := range students {
+			switch student.Result {
+			case PromotionPromoted:
+				summary.Promoted = summary.Promoted + 1
+				summary.FeesBilled = summary.FeesBilled + student.Fee
+				summary.DuesCarried = summary.DuesCarried + student.CarriedForward
+			case PromotionExcluded:
+				summary.Excluded = summary.Excluded + 1
+			default:
+				summary.Skipped = summary.Skipped + 1
+			}
+		}
+		report.Students = append(report.Students, students...)
+		report.Classes = append(report.Classes, summary)
+		report.Promoted = report.Promoted + summary.Promoted
+		report.Excluded = report.Excluded + summary.Excluded
+		report.Skipped = report.Skipped + summary.Skipped
+		report.FeesBilled = report.FeesBilled + summary.FeesBilled
+		report.DuesCarried = report.DuesCarried + summary.DuesCarried
+	}
+	if options.DryRun || report.Promoted == 0 {
+		return report, nil, nil
+	}
+	if InClosedFiscalYear(time.Now()) {
+		return nil, nil, swapErr.ErrFiscalYearClosed
+	}
+
+	reason := options.Reason
+	if reason == "" {
+		reason = "Year-end promotion"
+	}
+	mappings := map[uint]*PromotionMapping{}
+	for i := range options.Mappings {
+		mappings[options.Mappings[i].FromBatchStandardId] = &options.Mappings[i]
+	}
+	promotion := &Promotion{CarryForward: options.CarryForward, Reason: reason, Promoted: report.Promoted,
+		Excluded: report.Excluded, Skipped: report.Skipped, FeesBilled: report.FeesBilled,
+		DuesCarried: report.DuesCarried, UserID: userId}
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		for i := range report.Students {
+			student := &report.Students[i]
+			if student.Result != PromotionPromoted {
+				continue
+			}
+			if err := student.promote(tx, mappings[student.FromBatchStandardId], reason, userId); err != nil {
+				return err
+			}
+		}
+		reportJSON, _ := json.Marshal(report)
+		promotion.Report = string(reportJSON)
+		return tx.Create(promotion).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, mapping := range options.Mappings {
+		(&BatchStandardStudent{BatchStandardId: mapping.FromBatchStandardId}).updateCount(db.Driver)
+		(&BatchStandardStudent{BatchStandardId: mapping.ToBatchStandardId}).updateCount(db.Driver)
+	}
+	for i := range report.Students {
+		if report.Students[i].Result == PromotionPromoted {
+			student := &Student{ID: report.Students[i].StudentId}
+			if err := student.Find(); err == nil {
+				student.SaveBalance()
+			}
+		}
+	}
+	return report, promotion, nil
+}
+
+func checkPromotionMappings(mappings []PromotionMapping) error {
+	if len(mappings) == 0 {
+		return swapErr.ErrPromotionMapping
+	}
+	sources := map[uint]bool{}
+	for i := range mappings {
+		mapping := &mappings[i]
+		if mapping.FromBatchStandardId == mapping.ToBatchStandardId || sources[mapping.FromBatchStandardId] {
+			return swapErr.ErrPromotionMapping
+		}
+		sources[mapping.FromBatchStandardId] = true
+
+		mapping.from = &BatchStandard{ID: mapping.FromBatchStandardId}
+		mapping.to = &BatchStandard{ID: mapping.ToBatchStandardId}
+		if mapping.from.Find() != nil || mapping.to.Find() != nil {
+			return swapErr.ErrPromotionMapping
+		}
+		var err error
+		if mapping.category, err = mapping.to.GetTransactionCategory(); err != nil {
+			return err
+		}
+		if mapping.fromCategory, err = mapping.from.GetTransactionCategory(); err != nil {
+			return err
+		}
+		if mapping.fee, err = mapping.to.FeeOn(time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planPromotion decides for each student of the source class whether they
+// move up, in roll number order so the new roll numbers follow the old ones.
+func planPromotion(mapping *PromotionMapping, excluded map[uint]bool, carryForward bool) ([]PromotionStudent, error) {
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Preload("Student").Where("batch_standard_id = ?", mapping.FromBatchStandardId).
+		Order("roll_sequence").Order("id").Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, err
+	}
+	if mapping.to.RollNumberStrategy == "alphabetical" {
+		sort.SliceStable(batchStandardStudents, func(i, j int) bool {
+			return rollNumberName(batchStandardStudents[i].Student) < rollNumberName(batchStandardStudents[j].Student)
+		})
+	}
+
+	students := []PromotionStudent{}
+	for _, bss := range batchStandardStudents {
+		student := bss.Student
+		if student.ID == 0 {
+			// the student was deleted
+			continue
+		}
+		debits, credits := student.GetBalance()
+		promotion := PromotionStudent{StudentId: student.ID, Name: strings.TrimSpace(student.FirstName + " " + student.LastName),
+			FromBatchStandardId: mapping.FromBatchStandardId, ToBatchStandardId: mapping.ToBatchStandardId,
+			Balance: credits - debits, Result: PromotionPromoted, bss: bss, student: student}
+
+		var enrolled int64
+		db.Driver.Model(&BatchStandardStudent{}).Where("student_id = ? and batch_standard_id = ?", student.ID, mapping.ToBatchStandardId).Count(&enrolled)
+		switch {
+		case excluded[student.ID]:
+			promotion.Result, promotion.Reason = PromotionExcluded, "Excluded"
+		case !student.CanTransition(StudentPromoted) && student.CurrentStatus() != StudentPromoted:
+			promotion.Result, promotion.Reason = PromotionExcluded, "Status is " + student.CurrentStatus()
+		case enrolled > 0:
+			promotion.Result, promotion.Reason = PromotionSkipped, "Already in the target class"
+		case promotion.Balance < 0.0 && !carryForward:
+			promotion.Result, promotion.Reason = PromotionSkipped, swapErr.ErrBalanceNotCleared.Error()
+		default:
+			promotion.Fee = mapping.fee
+			if promotion.Balance < 0.0 {
+				promotion.CarriedForward = -promotion.Balance
+			}
+		}
+		students = append(students, promotion)
+	}
+	return students, nil
+}
+
+// promote ends the student's enrollment in the source class and enrolls them
+// in the target class with the next roll number and its fee, moving any dues
+// carried forward from the old enrollment to the new one.
+func (ps *PromotionStudent) promote(tx *gorm.DB, mapping *PromotionMapping, reason string, userId int) error {
+	if err := tx.Delete(&BatchStandardStudent{}, ps.bss.ID).Error; err != nil {
+		return err
+	}
+
+	sequence, err := mapping.to.nextRollSequence(tx)
+	if err != nil {
+		return err
+	}
+	bss := &BatchStandardStudent{BatchId: mapping.to.BatchId, StandardId: mapping.to.StandardId, StudentId: ps.StudentId,
+		BatchStandardId: mapping.to.ID, Fee: ps.Fee, RollSequence: sequence, RollNumber: mapping.to.FormatRollNumber(sequence)}
+	if err := tx.Omit("Standard", "Batch", "BatchStandard", "Student").Create(bss).Error; err != nil {
+		return err
+	}
+	ps.BatchStandardStudentId = bss.ID
+	ps.RollNumber = bss.RollNumber
+
+	transactions := []*Transaction{{Name: "Promotion Fee", StudentId: ps.StudentId, TransactionCategoryId: mapping.category.ID,
+		BatchStandardStudentId: bss.ID, IsCleared: true, PaidBy: "-", PaymentMode: "-", TransactionType: "debit", Amount: ps.Fee}}
+	if ps.CarriedForward > 0.0 {
+		transactions = append(transactions,
+			&Transaction{Name: "Dues Carried Forward", StudentId: ps.StudentId, TransactionCategoryId: mapping.fromCategory.ID,
+				BatchStandardStudentId: ps.bss.ID, IsCleared: true, PaidBy: "-", PaymentMode: "-", TransactionType: "credit",
+				Amount: ps.CarriedForward, Reason: reason},
+			&Transaction{Name: "Dues Brought Forward", StudentId: ps.StudentId, TransactionCategoryId: mapping.category.ID,
+				BatchStandardStudentId: bss.ID, IsCleared: true, PaidBy: "-", PaymentMode: "-", TransactionType: "debit",
+				Amount: ps.CarriedForward, Reason: reason})
+	}
+	for _, transaction := range transactions {
+		if transaction.Amount <= 0.0 {
+			continue
+		}
+		if err := tx.Omit("Student").Create(transaction).Error; err != nil {
+			return err
+		}
+	}
+
+	updates := map[string]interface{}{"roll_number": bss.RollNumber}
+	if ps.student.CurrentStatus() != StudentPromoted {
+		statusChange := &StudentStatusChange{StudentId: ps.StudentId, FromStatus: ps.student.CurrentStatus(),
+			ToStatus: StudentPromoted, Reason: reason, UserID: userId}
+		if err := tx.Create(statusChange).Error; err != nil {
+			return err
+		}
+		updates["status"] = StudentPromoted
+	}
+	if err := tx.Model(&Student{}).Where("id = ?", ps.StudentId).Updates(updates).Error; err != nil {
+		return err
+	}
+	return indexStudent(tx, ps.StudentId)
+}
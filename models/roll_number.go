@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"swapnil-ex/models/db"
+	"gorm.io/gorm"
+)
+
+var rollNumberDigits = regexp.MustCompile("#+")
+
+// FormatRollNumber renders the roll sequence of a student in the class. The
+// custom strategy uses RollNumberFormat, where a run of # is replaced by the
+// zero padded sequence ("10A-###" gives "10A-007"); without # the sequence is
+// appended to the format as a prefix.
+func (bs *BatchStandard) FormatRollNumber(sequence int) string {
+	if bs.RollNumberStrategy != "custom" || bs.RollNumberFormat == "" {
+		return strconv.Itoa(sequence)
+	}
+	digits := rollNumberDigits.FindString(bs.RollNumberFormat)
+	if digits == "" {
+		return bs.RollNumberFormat + strconv.Itoa(sequence)
+	}
+	return strings.Replace(bs.RollNumberFormat, digits, fmt.Sprintf("%0*d", len(digits), sequence), 1)
+}
+
+// nextRollSequence is one past the highest sequence used in the class. New
+// students are added at the end until the class is renumbered.
+func (bs *BatchStandard) nextRollSequence(tx *gorm.DB) (int, error) {
+	var sequence int
+	err := tx.Model(&BatchStandardStudent{}).Where("batch_standard_id = ?", bs.ID).
+		Select("coalesce(max(roll_sequence), 0)").Scan(&sequence).Error
+	return sequence + 1, err
+}
+
+// AssignRollNumber gives the student the next roll number of the class.
+func (bss *BatchStandardStudent) AssignRollNumber(batchStandard *BatchStandard) error {
+	if bss.RollNumber != "" {
+		return nil
+	}
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		sequence, err := batchStandard.nextRollSequence(tx)
+		if err != nil {
+			return err
+		}
+		bss.RollSequence = sequence
+		bss.RollNumber = batchStandard.FormatRollNumber(sequence)
+		err = tx.Model(&BatchStandardStudent{}).Where("id = ?", bss.ID).
+			Updates(map[string]interface{}{"roll_sequence": bss.RollSequence, "roll_number": bss.RollNumber}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&Student{}).Where("id = ?", bss.StudentId).Update("roll_number", bss.RollNumber).Error; err != nil {
+			return err
+		}
+		return indexStudent(tx, bss.StudentId)
+	})
+}
+
+// RenumberRollNumbers reassigns the roll numbers of the whole class from 1,
+// ordered by the class strategy: by surname for alphabetical, otherwise by
+// admission date, the date the student was created. Ties go by enrollment.
+func (bs *BatchStandard) RenumberRollNumbers() ([]BatchStandardStudent, error) {
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Preload("Student").Where("batch_standard_id = ?", bs.ID).Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(batchStandardStudents, func(i, j int) bool {
+		a, b := batchStandardStudents[i], batchStandardStudents[j]
+		if bs.RollNumberStrategy == "alphabetical" {
+			if nameA, nameB := rollNumberName(a.Student), rollNumberName(b.Student); nameA != nameB {
+				return nameA < nameB
+			}
+		}
+		if !a.Student.CreatedAt.Equal(b.Student.CreatedAt) {
+			return a.Student.CreatedAt.Before(b.Student.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		// clear first so the unique index does not trip over old numbers
+		err := tx.Model(&BatchStandardStudent{}).Where("batch_standard_id = ?", bs.ID).
+			Updates(map[string]interface{}{"roll_sequence": 0, "roll_number": ""}).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range batchStandardStudents {
+			bss := &batchStandardStudents[i]
+			bss.RollSequence = i + 1
+			bss.RollNumber = bs.FormatRollNumber(bss.RollSequence)
+			bss.Student.RollNumber = bss.RollNumber
+			err := tx.Model(&BatchStandardStudent{}).Where("id = ?", bss.ID).
+				Updates(map[string]interface{}{"roll_sequence": bss.RollSequence, "roll_number": bss.RollNumber}).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&Student{}).Where("id = ?", bss.StudentId).Update("roll_number", bss.RollNumber).Error; err != nil {
+				return err
+			}
+			if err := indexStudent(tx, bss.StudentId); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return batchStandardStudents, err
+}
+
+func rollNumberName(s Student) string {
+	return strings.ToLower(s.LastName + " " + s.FirstName + " " + s.MiddleName)
+}
@@ -50,12 +50,20 @@ func (s *Standard) Assign(standardData map[string]interface{}) {
 	}
 }
 
-func (s *Standard) All() ([]Standard, error) {
+var StandardListFields = ListFields{"id": "id", "name": "name", "std": "std", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (s *Standard) All(lq *ListQuery) ([]Standard, error) {
 	var standards []Standard
-	err := db.Driver.Find(&standards).Error
+	err := db.Driver.Scopes(lq.Scope).Find(&standards).Error
 	return standards, err
 }
 
+func (s *Standard) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&Standard{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
 func (s *Standard) AllExcept(standardIds []uint) ([]Standard, error) {
 	var standards []Standard
 	var err error
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"errors"
 	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
 	"time"
 	"gorm.io/gorm"
 	"gopkg.in/validator.v2"
@@ -25,6 +26,9 @@ type Student struct {
 	WhNumber									string  `json:"wh_number" validate:"nonzero,min=10,max=12"`
 	Status 										string `json:"status"`
 	Town 											string `json:"town" validate:"nonzero"`
+	BloodGroup 								string `json:"blood_group" validate:"regexp=^((A|B|AB|O)[+-])?$"`
+	EmergencyContact 					string `json:"emergency_contact"`
+	CustomFields 							map[string]interface{} `json:"custom_fields" gorm:"serializer:json"`
 	HasHostel									bool `json:"has_hostel" gorm:"default:false"`
 	Balance 									float64 `json:"balance" gorm:"default:0.0"`
 	StudentAccountBalance 		float64 `json:"student_account_balance" gorm:"default:0.0"`
@@ -43,21 +47,44 @@ func migrateStudent() {
 }
 
 func NewStudent(studentData map[string]interface{}) *Student {
-	student := &Student{}
+	student := &Student{Status: StudentEnquiry}
+	if status, ok := studentData["status"]; ok && status == StudentAdmission {
+		student.Status = StudentAdmission
+	}
 	student.Assign(studentData)
 	return student
 }
 
+// Validate checks the student fields and the custom field values.
 func (s *Student) Validate() error {
-	if errs := validator.Validate(s); errs != nil {
+	errs := validator.ErrorMap{}
+	if err := validator.Validate(s); err != nil {
+		errs = err.(validator.ErrorMap)
+	}
+	if _, failed := errs["AdharCard"]; !failed {
+		changed, err := s.aadhaarChanged()
+		if err != nil {
+			return err
+		}
+		if changed && !ValidAadhaar(s.AdharCard) {
+			errs["AdharCard"] = validator.ErrorArray{swapErr.ErrInvalidAadhaar}
+		}
+	}
+	customErrs, err := s.validateCustomFields()
+	if err != nil {
+		return err
+	}
+	for name, customErr := range customErrs {
+		errs[name] = customErr
+	}
+	if len(errs) > 0 {
 		return errs
-	} else {
-		return nil
 	}
+	return nil
 }
 
 func (s *Student) AssignClass() error {	
-	if s.Status == "Admission" {
+	if s.Status == StudentAdmission {
 		return nil
 	} else {
 		return errors.New("Already assigned Class")
@@ -102,36 +129,105 @@ func (s *Student) Assign(studentData map[string]interface{}) {
 	if town, ok := studentData["town"]; ok {
 		s.Town = town.(string)
 	}
+
+	if bloodGroup, ok := studentData["blood_group"]; ok {
+		s.BloodGroup, _ = bloodGroup.(string)
+	}
+
+	if emergencyContact, ok := studentData["emergency_contact"]; ok {
+		s.EmergencyContact, _ = emergencyContact.(string)
+	}
+
+	// custom fields are merged, a null value clears one
+	if customFields, ok := studentData["custom_fields"].(map[string]interface{}); ok {
+		if s.CustomFields == nil {
+			s.CustomFields = map[string]interface{}{}
+		}
+		for key, value := range customFields {
+			if value == nil {
+				delete(s.CustomFields, key)
+			} else {
+				s.CustomFields[key] = value
+			}
+		}
+	}
 }
 
-func (s *Student) All(page int, search string) ([]Student, error) {
-	var students []Student
-	query := db.Driver.Limit(10).Offset((page - 1) * 10)
-	search = strings.Trim(search, " ")
-	if len([]rune(search)) > 0 {
-		search = "%" + search + "%"
-		query = query.Where("first_name like ? or middle_name like ? or last_name like ? OR contact_number like ?", search, search, search, search)
+var StudentListFields = ListFields{"id": "id", "inil": "inil", "first_name": "first_name", "middle_name": "middle_name",
+	"last_name": "last_name", "roll_number": "roll_number", "birth_date": "birth_date", "adhar_card": "",
+	"parent_name": "parent_name", "parent_occupation": "parent_occupation", "contact_number": "contact_number",
+	"wh_number": "wh_number", "status": "status", "town": "town", "blood_group": "blood_group",
+	"emergency_contact": "emergency_contact", "has_hostel": "has_hostel", "balance": "balance",
+	"student_account_balance": "student_account_balance", "custom_fields": "", "created_at": "created_at", "updated_at": "updated_at"}
+
+// All returns a page of students, those matching search when given. With
+// the search index and no sort asked for, matches come best first and fuzzy
+// tolerates typos.
+func (s *Student) All(lq *ListQuery, search string, fuzzy bool, tags []string) ([]Student, error) {
+	students := []Student{}
+	query, ranked, err := studentListQuery(lq, search, fuzzy, tags)
+	if err != nil {
+		return students, err
 	}
-	err := query.Find(&students).Error
+
+	if ranked != nil && len(lq.Sort) == 0 {
+		var ids []uint
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return students, err
+		}
+		matched := map[uint]bool{}
+		for _, id := range ids {
+			matched[id] = true
+		}
+		ordered := []uint{}
+		for _, id := range ranked {
+			if matched[id] {
+				ordered = append(ordered, id)
+			}
+		}
+		return findStudentsInOrder(pageIds(ordered, lq.Page, lq.PageSize))
+	}
+
+	// id only breaks ties, so it goes after the sort asked for
+	err = lq.Paginate(lq.Order(query)).Order("id").Find(&students).Error
 	return students, err
 }
 
-func (s *Student) Count(search string) (int64, error) {
+func (s *Student) Count(lq *ListQuery, search string, fuzzy bool, tags []string) (int64, error) {
 	var count int64
-	query := db.Driver.Model(&Student{})
-	search = strings.Trim(search, " ")
-	if len([]rune(search)) > 0 {
-		search = "%" + search + "%"
-		query = query.Where("first_name like ? or middle_name like ? or last_name like ? OR contact_number like ?", search, search, search, search)
+	query, _, err := studentListQuery(lq, search, fuzzy, tags)
+	if err != nil {
+		return count, err
 	}
-	err := query.Count(&count).Error
+	err = query.Count(&count).Error
 	return count, err
 }
 
-func (s *Student) SearchIds(search string) (error, []uint){
+// studentListQuery filters students by lq, search and tags, returning the
+// ranked ids of the search index matches when it was used.
+func studentListQuery(lq *ListQuery, search string, fuzzy bool, tags []string) (*gorm.DB, []uint, error) {
+	query := taggedStudents(db.Driver.Model(&Student{}).Scopes(lq.Filter), tags)
+	search = strings.Trim(search, " ")
+	if len([]rune(search)) == 0 {
+		return query, nil, nil
+	}
+	if studentSearchEnabled {
+		ids, err := searchStudentIds(search, fuzzy)
+		return query.Where("id in (?)", ids), ids, err
+	}
+	search = "%" + search + "%"
+	return query.Where("(first_name like ? or middle_name like ? or last_name like ? OR contact_number like ?)", search, search, search, search), nil, nil
+}
+
+func (s *Student) SearchIds(search string, fuzzy bool) (error, []uint){
 	var ids []uint
-	query := db.Driver.Model(&Student{})
 	search = strings.Trim(search, " ")
+	if studentSearchEnabled && len([]rune(search)) > 0 {
+		ids, err := searchStudentIds(search, fuzzy)
+		return err, ids
+	}
+
+	query := db.Driver.Model(&Student{})
 	if len([]rune(search)) > 0 {
 		search = "%" + search + "%"
 		query = query.Where("first_name like ? or middle_name like ? or last_name like ? OR contact_number like ? ", search, search, search, search)
@@ -140,32 +236,131 @@ func (s *Student) SearchIds(search string) (error, []uint){
 	return err, ids
 }
 
+func pageIds(ids []uint, page int, pageSize int) []uint {
+	if pageSize == 0 {
+		return ids
+	}
+	start := (page - 1) * pageSize
+	if start < 0 || start >= len(ids) {
+		return []uint{}
+	}
+	end := start + pageSize
+	if end > len(ids) {
+		end = len(ids)
+	}
+	return ids[start:end]
+}
+
+// findStudentsInOrder loads the students keeping the order of ids.
+func findStudentsInOrder(ids []uint) ([]Student, error) {
+	students := []Student{}
+	if len(ids) == 0 {
+		return students, nil
+	}
+	var found []Student
+	if err := db.Driver.Where("id in (?)", ids).Find(&found).Error; err != nil {
+		return students, err
+	}
+	byId := map[uint]Student{}
+	for _, student := range found {
+		byId[student.ID] = student
+	}
+	for _, id := range ids {
+		if student, ok := byId[id]; ok {
+			students = append(students, student)
+		}
+	}
+	return students, nil
+}
+
 func (s *Student) Find() error {
 	err := db.Driver.First(s, "ID = ?", s.ID).Error
 	return err
 }
 
+// Create saves the student and links it to its parent as primary guardian,
+// in one transaction.
 func (s *Student) Create() error {
-	err := db.Driver.Create(s).Error
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		return s.create(tx)
+	})
+	if err != nil {
+		s.ID = 0
+	}
 	return err
 }
 
+func (s *Student) create(tx *gorm.DB) error {
+	if err := tx.Create(s).Error; err != nil {
+		return err
+	}
+	if err := indexStudent(tx, s.ID); err != nil {
+		return err
+	}
+	return s.linkParentGuardian(tx)
+}
+
+// Update saves a profile edit of the student, carrying changed parent
+// columns over to the primary guardian. Bookkeeping columns such as the
+// balance are updated on their own instead.
 func (s *Student) Update() error {
-	err := db.Driver.Save(s).Error
-	return err
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(s).Error; err != nil {
+			return err
+		}
+		if err := indexStudent(tx, s.ID); err != nil {
+			return err
+		}
+		return s.syncPrimaryGuardian(tx)
+	})
 }
 
+// Delete moves the student to the trash along with their enrollments, hostel
+// stays, transactions, wallet entries, documents and notes, all stamped with
+// the same time so RestoreTrash brings them back together. A student with
+// transactions in a closed fiscal year cannot be deleted.
 func (s *Student) Delete() error {
-	err := db.Driver.Delete(s).Error
-	return err
+	var batchStandardStudents []BatchStandardStudent
+	var hostelStudents []HostelStudent
+	db.Driver.Where("student_id = ?", s.ID).Find(&batchStandardStudents)
+	db.Driver.Where("student_id = ?", s.ID).Find(&hostelStudents)
+	now := time.Now()
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		closed, err := hasClosedYearTransactions(tx, s.ID)
+		if err != nil {
+			return err
+		}
+		if closed {
+			return swapErr.ErrFiscalYearClosed
+		}
+		for _, child := range trashEntities["students"].children {
+			if err := tx.Table(child.table).Where(child.column + " = ? and deleted_at is null", s.ID).Update("deleted_at", now).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Table("students").Where("id = ?", s.ID).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return unindexStudent(tx, s.ID)
+	})
+	if err != nil {
+		return err
+	}
+	for i := range batchStandardStudents {
+		batchStandardStudents[i].updateCount(db.Driver)
+	}
+	for i := range hostelStudents {
+		hostelStudents[i].updateCount(db.Driver)
+	}
+	return nil
 }
 
 func (s *Student) AdmissionStatus() bool {
-	return s.Status == "Admission"
+	return s.Status == StudentAdmission
 }
 
 func (s *Student) ConfirmedStatus() bool {
-	return s.Status == "Confirmed"
+	return s.Status == StudentConfirmed
 }
 
 func (s *Student) GetBatchStandardStudents() []BatchStandardStudent {
@@ -177,63 +372,130 @@ func (s *Student) GetBatchStandardStudents() []BatchStandardStudent {
 func (s *Student) RemoveBatchStandard(batchStandard *BatchStandard) error {
 	totalDebits, totalCredits := s.GetBalance()
 	balance := totalCredits - totalDebits
-	if balance > 0.0 {
+	// dues are a negative balance, an advance a positive one
+	if balance < 0.0 {
 		return errors.New("Please Clear Balance first")
 	}
 
-	batchStandardStudent := &BatchStandardStudent{StudentId: s.ID, BatchStandardId: batchStandard.ID}
-	err := db.Driver.Find(batchStandardStudent).Error
+	batchStandardStudent := &BatchStandardStudent{}
+	err := db.Driver.Where("student_id = ? and batch_standard_id = ?", s.ID, batchStandard.ID).First(batchStandardStudent).Error
 	if err != nil {
 		return err
 	}
-	return batchStandardStudent.Delete()
+	err = batchStandardStudent.Delete()
+	if err == nil {
+		batchStandardStudent.updateCount(db.Driver)
+	}
+	return err
 }
 
 func (s *Student) AssignBatchStandard(batchStandard *BatchStandard) error {
-	//check student already assign to batch standard
-	//if assign remove from current batch standard before this check transaction 
-	// after that assign new batch standard
-	batchStandardStudents := s.GetBatchStandardStudents()
-	if len(batchStandardStudents) > 0 {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		return s.assignBatchStandard(tx, batchStandard)
+	})
+}
+
+// assignBatchStandard enrolls the student through tx with the fee in effect
+// and the next roll number of the class, billing the fee. The student
+// balance is left to the caller.
+func (s *Student) assignBatchStandard(tx *gorm.DB, batchStandard *BatchStandard) error {
+	var enrolled int64
+	if err := tx.Model(&BatchStandardStudent{}).Where("student_id = ?", s.ID).Count(&enrolled).Error; err != nil {
+		return err
+	}
+	if enrolled > 0 {
 		return errors.New("Already assigned to Class")
-	} else {
-		batchStandardStudent := &BatchStandardStudent{}
-		
-		batchStandardStudent.BatchId = batchStandard.BatchId
-		batchStandardStudent.StandardId = batchStandard.StandardId
-		batchStandardStudent.StudentId = s.ID
-		batchStandardStudent.BatchStandardId = batchStandard.ID
-		batchStandardStudent.Fee = batchStandard.Fee
-		
-		return batchStandardStudent.Create()
 	}
-	
-} 
+	fee, err := batchStandard.FeeOn(time.Now())
+	if err != nil {
+		return err
+	}
+	sequence, err := batchStandard.nextRollSequence(tx)
+	if err != nil {
+		return err
+	}
+
+	batchStandardStudent := &BatchStandardStudent{BatchId: batchStandard.BatchId, StandardId: batchStandard.StandardId, StudentId: s.ID,
+		BatchStandardId: batchStandard.ID, Fee: fee, RollSequence: sequence, RollNumber: batchStandard.FormatRollNumber(sequence)}
+	if err := tx.Omit("Standard", "Batch", "BatchStandard", "Student").Create(batchStandardStudent).Error; err != nil {
+		return err
+	}
+	if err := batchStandardStudent.updateCount(tx); err != nil {
+		return err
+	}
+	if err := batchStandardStudent.addTransaction(tx, batchStandard); err != nil {
+		return err
+	}
+	s.RollNumber = batchStandardStudent.RollNumber
+	if err := tx.Model(&Student{}).Where("id = ?", s.ID).Update("roll_number", s.RollNumber).Error; err != nil {
+		return err
+	}
+	return indexStudent(tx, s.ID)
+}
 
 func (s *Student) AssignHostel(h *Hostel, hr *HostelRoom, fee_included bool) error {
-	var hostelStudent = HostelStudent{StudentId: s.ID, HostelId: h.ID, HostelRoomId: hr.ID}
-	err := db.Driver.Where("student_id = ?",s.ID).First(&hostelStudent).Error
-	hostelStudent.FeeIncluded = fee_included
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		return s.assignHostel(tx, h, hr, fee_included)
+	})
 	if err != nil {
-		err = hostelStudent.Create()
-		if err == nil {
-			s.HasHostel = true
-			s.Update()
-			s.SaveBalance()
-		}
+		return err
 	}
-	return err
+	return s.SaveBalance()
+}
+
+// assignHostel puts the student in the hostel room through tx and bills the
+// stay, unless the student already has one. The student balance is left to
+// the caller.
+func (s *Student) assignHostel(tx *gorm.DB, h *Hostel, hr *HostelRoom, feeIncluded bool) error {
+	hostelStudent := &HostelStudent{}
+	if err := tx.Where("student_id = ?", s.ID).Limit(1).Find(hostelStudent).Error; err != nil || hostelStudent.ID > 0 {
+		return err
+	}
+
+	hostelStudent = &HostelStudent{StudentId: s.ID, HostelId: h.ID, HostelRoomId: hr.ID, FeeIncluded: feeIncluded}
+	if err := tx.Create(hostelStudent).Error; err != nil {
+		return err
+	}
+	if err := hostelStudent.updateCount(tx); err != nil {
+		return err
+	}
+	if err := hostelStudent.addTransaction(tx); err != nil {
+		return err
+	}
+	s.HasHostel = true
+	return tx.Model(&Student{}).Where("id = ?", s.ID).Update("has_hostel", true).Error
 }
 
 func (s *Student) ChangeHostel(h *Hostel, hr *HostelRoom) error {
+	_, err := s.TransferHostel(h, hr, time.Now(), 0, "")
+	return err
+}
+
+func (s *Student) TransferHostel(h *Hostel, hr *HostelRoom, effectiveOn time.Time, userId int, reason string) (*HostelTransfer, error) {
 	var hostelStudent = HostelStudent{StudentId: s.ID}
 	err := db.Driver.Where("student_id = ?",s.ID).First(&hostelStudent).Error
+	if err != nil {
+		return nil, err
+	}
+	transfer, err := hostelStudent.Transfer(h, hr, effectiveOn, userId, reason)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Find()
+	return transfer, err
+}
+
+func (s *Student) LeaveHostel(leftOn time.Time) error {
+	hostelStudent, err := s.GetStudentHostel()
+	if err != nil {
+		return err
+	}
+	err = hostelStudent.Leave(leftOn)
 	if err == nil {
-		hostelStudent.HostelId = h.ID
-		hostelStudent.HostelRoomId = hr.ID
-		s.HasHostel = true
-		s.Update()
-		return hostelStudent.Update()
+		if err = s.Find(); err == nil {
+			s.HasHostel = false
+			err = db.Driver.Model(s).Update("has_hostel", false).Error
+		}
 	}
 	return err
 }
@@ -262,7 +524,7 @@ func (s *Student) TotalDebits() float64 {
 	var total = 0.0
 	if err == nil {
 		for _, transaction := range transactions {
-			if transaction.TransactionType == "debit" {
+			if transaction.TransactionType == "debit" && !transaction.IsOpeningBalance {
 				total = total + transaction.Amount
 			}
 		}
@@ -275,7 +537,7 @@ func (s *Student) TotalCridits() float64 {
 	var total = 0.0
 	if err == nil {
 		for _, transaction := range transactions {
-			if transaction.TransactionType == "credit" {
+			if transaction.TransactionType == "credit" && !transaction.IsOpeningBalance {
 				total = total + transaction.Amount
 			}
 		}
@@ -283,10 +545,20 @@ func (s *Student) TotalCridits() float64 {
 	return total
 }
 
+// saveStudentBalance recomputes the balance of the student studentId once
+// transactions posted to them inside a database transaction are committed.
+func saveStudentBalance(studentId uint) error {
+	student := &Student{ID: studentId}
+	if err := student.Find(); err != nil {
+		return err
+	}
+	return student.SaveBalance()
+}
+
 func (s *Student) SaveBalance() error{
 	debits, credits := s.GetBalance()
 	s.Balance =  credits - debits
-	return s.Update()
+	return db.Driver.Model(s).Update("balance", s.Balance).Error
 }
 
 func (s *Student) GetBalance() (float64, float64) {
@@ -295,6 +567,9 @@ func (s *Student) GetBalance() (float64, float64) {
 	var totalCredits = 0.0
 	if err == nil {
 		for _, transaction := range transactions {
+			if transaction.IsOpeningBalance {
+				continue
+			}
 			if transaction.TransactionType == "debit" {
 				totalDebits = totalDebits + transaction.Amount
 			} else {
@@ -330,5 +605,5 @@ func (s *Student) GetStudentAccountBalance() (float64, float64) {
 func (s *Student) SaveStudentAccountBalance() error{
 	debits, credits := s.GetStudentAccountBalance()
 	s.StudentAccountBalance =  credits - debits
-	return s.Update()
+	return db.Driver.Model(s).Update("student_account_balance", s.StudentAccountBalance).Error
 }
\ No newline at end of file
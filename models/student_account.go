@@ -15,6 +15,8 @@ type StudentAccount struct {
 	Amount       						float64 `json:"amount" validate:"nonzero"`
 	Balance 								float64 `json:"balance" gorm:"default:0.0"`
 	UserID									uint `json:"user_id"`
+	Reason 									string `json:"reason"`
+	PosSaleId 							uint `json:"pos_sale_id"`
 	Student 								Student
 	CreatedAt 							time.Time
 	UpdatedAt 							time.Time
@@ -53,19 +55,23 @@ func (sa *StudentAccount) Assign(studentAccountData map[string]interface{}) {
 	}
 }
 
-func (sa *StudentAccount) AllStudentAccounts(page int, ids []uint) ([]StudentAccount, error) {
+var StudentAccountListFields = ListFields{"id": "id", "student_id": "student_id", "transaction_type": "transaction_type",
+	"amount": "amount", "balance": "balance", "user_id": "user_id", "reason": "reason", "pos_sale_id": "pos_sale_id",
+	"student": "", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (sa *StudentAccount) AllStudentAccounts(lq *ListQuery, ids []uint) ([]StudentAccount, error) {
 	var studentAccounts []StudentAccount
 	query := db.Driver.Preload("Student")
 	if len(ids) > 0 {	
 		query = query.Where("student_id in (?)", ids)
 	}
-	err := query.Limit(10).Offset((page - 1) * 10).Order("id desc").Find(&studentAccounts).Error
+	err := query.Scopes(lq.Scope).Find(&studentAccounts).Error
 	return studentAccounts, err
 }
 
-func (sa *StudentAccount) Count(ids []uint) (int64, error) {
+func (sa *StudentAccount) Count(lq *ListQuery, ids []uint) (int64, error) {
 	var count int64
-	query := db.Driver.Model(&StudentAccount{})
+	query := db.Driver.Model(&StudentAccount{}).Scopes(lq.Filter)
 	if len(ids) > 0 {	
 		query = query.Where("student_id in (?)", ids)
 	}
@@ -73,9 +79,9 @@ func (sa *StudentAccount) Count(ids []uint) (int64, error) {
 	return count, err
 }
 
-func (sa *StudentAccount) All(studentId int) ([]StudentAccount, error) {
+func (sa *StudentAccount) All(lq *ListQuery, studentId int) ([]StudentAccount, error) {
 	var studentAccounts []StudentAccount
-	err := db.Driver.Where("student_id = ?", studentId).Find(&studentAccounts).Error
+	err := db.Driver.Where("student_id = ?", studentId).Scopes(lq.Scope).Find(&studentAccounts).Error
 	return studentAccounts, err
 }
 
@@ -97,4 +103,17 @@ func (sa *StudentAccount) Update() error {
 func (sa *StudentAccount) Delete() error {
 	err := db.Driver.Delete(sa).Error
 	return err
-}
\ No newline at end of file
+}
+
+// lockedStudentAccountBalance locks the student row for the rest of tx and
+// sums their wallet entries, so a debit checked against it cannot race
+// another one.
+func lockedStudentAccountBalance(tx *gorm.DB, studentId uint) (float64, error) {
+	if err := tx.Model(&Student{}).Where("id = ?", studentId).Update("updated_at", time.Now()).Error; err != nil {
+		return 0.0, err
+	}
+	var balance float64
+	err := tx.Model(&StudentAccount{}).Select("coalesce(sum(case when transaction_type = 'debit' then -amount else amount end), 0)").
+		Where("student_id = ?", studentId).Scan(&balance).Error
+	return balance, err
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"swapnil-ex/constants"
+	"swapnil-ex/models/db"
+	"swapnil-ex/storage"
+	"swapnil-ex/swapErr"
+	"sync"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+// RequiredDocuments must be on file for every enrolled student.
+var RequiredDocuments = []string{"aadhaar", "birth_certificate", "photo"}
+
+var documentTypes = map[string][]string{
+	"aadhaar": {"application/pdf", "image/jpeg", "image/png"},
+	"birth_certificate": {"application/pdf", "image/jpeg", "image/png"},
+	"leaving_certificate": {"application/pdf", "image/jpeg", "image/png"},
+	"photo": {"image/jpeg", "image/png"},
+	"other": {"application/pdf", "image/jpeg", "image/png"},
+}
+
+var documentStorage storage.Storage
+var documentStorageOnce sync.Once
+
+func DocumentStorage() storage.Storage {
+	documentStorageOnce.Do(func() {
+		documentStorage = storage.FromEnv()
+	})
+	return documentStorage
+}
+
+type StudentDocument struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" validate:"nonzero" gorm:"index"`
+	Category 				string `json:"category" validate:"regexp=^(aadhaar|birth_certificate|leaving_certificate|photo|other)$"`
+	FileName 				string `json:"file_name" validate:"nonzero"`
+	ContentType 		string `json:"content_type"`
+	Size 						int64 `json:"size"`
+	Checksum 				string `json:"checksum"`
+	StorageKey 			string `json:"-"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+type MissingDocuments struct {
+	StudentId 	uint `json:"student_id"`
+	RollNumber 	string `json:"roll_number"`
+	Name 				string `json:"name"`
+	Missing 		[]string `json:"missing"`
+}
+
+func migrateStudentDocument() {
+	fmt.Println("migrating student document..")
+	err := db.Driver.AutoMigrate(&StudentDocument{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func (sd *StudentDocument) All(studentId uint) ([]StudentDocument, error) {
+	var documents []StudentDocument
+	err := db.Driver.Where("student_id = ?", studentId).Order("category, id desc").Find(&documents).Error
+	return documents, err
+}
+
+func (sd *StudentDocument) Find() error {
+	err := db.Driver.First(sd, "ID = ?", sd.ID).Error
+	return err
+}
+
+// Upload checks the size and detected type of the file for the category,
+// stores it and records it with its SHA-256 checksum.
+func (sd *StudentDocument) Upload(file io.Reader) error {
+	if err := validator.Validate(sd); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(io.LimitReader(file, constants.DOCUMENT_MAX_SIZE + 1))
+	if err != nil {
+		return err
+	}
+	if len(body) > constants.DOCUMENT_MAX_SIZE {
+		return swapErr.ErrDocumentTooLarge
+	}
+
+	sd.ContentType = http.DetectContentType(body)
+	allowed := false
+	for _, contentType := range documentTypes[sd.Category] {
+		if sd.ContentType == contentType {
+			allowed = true
+		}
+	}
+	if !allowed || len(body) == 0 {
+		return swapErr.ErrDocumentType
+	}
+
+	sum := sha256.Sum256(body)
+	sd.Checksum = hex.EncodeToString(sum[:])
+	sd.Size = int64(len(body))
+	sd.FileName = path.Base(sd.FileName)
+	sd.StorageKey = fmt.Sprintf("students/%d/%s/%s-%s", sd.StudentId, sd.Category, sd.Checksum[:16], sd.FileName)
+	if err := DocumentStorage().Put(sd.StorageKey, body, sd.ContentType); err != nil {
+		return err
+	}
+	return db.Driver.Create(sd).Error
+}
+
+func (sd *StudentDocument) Open() (io.ReadCloser, error) {
+	return DocumentStorage().Get(sd.StorageKey)
+}
+
+// Delete moves the document to the trash. The stored file is kept until the
+// document is purged.
+func (sd *StudentDocument) Delete() error {
+	return db.Driver.Delete(sd).Error
+}
+
+// MissingRequiredDocuments lists the students of a class who lack any of the
+// RequiredDocuments, by roll number.
+func (bs *BatchStandard) MissingRequiredDocuments() ([]MissingDocuments, error) {
+	var batchStandardStudents []BatchStandardStudent
+	err := db.Driver.Preload("Student").Where("batch_standard_id = ?", bs.ID).Order("roll_sequence, id").Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	studentIds := []uint{}
+	for _, bss := range batchStandardStudents {
+		studentIds = append(studentIds, bss.StudentId)
+	}
+	var documents []StudentDocument
+	if len(studentIds) > 0 {
+		err = db.Driver.Select("student_id, category").Where("student_id in (?)", studentIds).Find(&documents).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	onFile := map[uint]map[string]bool{}
+	for _, document := range documents {
+		if onFile[document.StudentId] == nil {
+			onFile[document.StudentId] = map[string]bool{}
+		}
+		onFile[document.StudentId][document.Category] = true
+	}
+
+	report := []MissingDocuments{}
+	for _, bss := range batchStandardStudents {
+		missing := []string{}
+		for _, category := range RequiredDocuments {
+			if !onFile[bss.StudentId][category] {
+				missing = append(missing, category)
+			}
+		}
+		if len(missing) > 0 {
+			report = append(report, MissingDocuments{StudentId: bss.StudentId, RollNumber: bss.RollNumber,
+				Name: bss.Student.FirstName + " " + bss.Student.LastName, Missing: missing})
+		}
+	}
+	return report, nil
+}
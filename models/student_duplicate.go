@@ -0,0 +1,104 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"swapnil-ex/models/db"
+)
+
+const duplicateNameSimilarity = 0.85
+
+type DuplicateCandidate struct {
+	Student 	Student `json:"student"`
+	Score 		float64 `json:"score"`
+	Reasons 	[]string `json:"reasons"`
+}
+
+// FindDuplicates returns up to five existing students that look like s: the
+// same Aadhaar number, a shared contact number or a similar name, best match
+// first.
+func (s *Student) FindDuplicates() ([]DuplicateCandidate, error) {
+	var students []Student
+	err := db.Driver.Where("id <> ?", s.ID).Find(&students).Error
+	if err != nil {
+		return nil, err
+	}
+
+	name := duplicateName(*s)
+	phones := map[string]bool{}
+	for _, phone := range []string{s.ContactNumber, s.WhNumber} {
+		if phone = strings.TrimSpace(phone); phone != "" {
+			phones[phone] = true
+		}
+	}
+
+	candidates := []DuplicateCandidate{}
+	for _, student := range students {
+		candidate := DuplicateCandidate{Student: student, Reasons: []string{}}
+		if s.AdharCard != "" && student.AdharCard == s.AdharCard {
+			candidate.Score = candidate.Score + 0.6
+			candidate.Reasons = append(candidate.Reasons, "adhar_card")
+		}
+		if phones[strings.TrimSpace(student.ContactNumber)] || phones[strings.TrimSpace(student.WhNumber)] {
+			candidate.Score = candidate.Score + 0.2
+			candidate.Reasons = append(candidate.Reasons, "contact_number")
+		}
+		if similarity := nameSimilarity(name, duplicateName(student)); similarity >= duplicateNameSimilarity {
+			candidate.Score = candidate.Score + 0.2 * similarity
+			candidate.Reasons = append(candidate.Reasons, "name")
+		}
+		// a shared phone alone is usually a sibling
+		if len(candidate.Reasons) == 0 || (len(candidate.Reasons) == 1 && candidate.Reasons[0] == "contact_number") {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > 5 {
+		candidates = candidates[:5]
+	}
+	return candidates, nil
+}
+
+func duplicateName(s Student) string {
+	return strings.ToLower(strings.Join(strings.Fields(s.FirstName + " " + s.MiddleName + " " + s.LastName), " "))
+}
+
+// nameSimilarity is 1 minus the edit distance over the longer name length.
+func nameSimilarity(a string, b string) float64 {
+	if a == "" || b == "" {
+		return 0.0
+	}
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb) + 1)
+	current := make([]int, len(rb) + 1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i - 1] == rb[j - 1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j - 1] + 1 < current[j] {
+				current[j] = current[j - 1] + 1
+			}
+			if previous[j - 1] + cost < current[j] {
+				current[j] = previous[j - 1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1.0 - float64(previous[len(rb)]) / float64(longest)
+}
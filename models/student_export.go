@@ -0,0 +1,228 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"swapnil-ex/models/db"
+	"swapnil-ex/sheet"
+	"swapnil-ex/swapErr"
+	"time"
+)
+
+type StudentExportFilter struct {
+	BatchId 		uint
+	StandardId 	uint
+	HostelId 		uint
+	Status 			string
+	Town 				string
+	MinBalance 	*float64
+	MaxBalance 	*float64
+	Tags 				[]string
+	Segment 		*StudentSegment
+}
+
+type exportColumn struct {
+	Name 		string
+	Select 	string
+}
+
+// studentExportColumns are the columns that can be exported, in their
+// default order. Enrollment and hostel columns are empty when not assigned.
+// Aadhaar numbers are masked as in the API.
+var studentExportColumns = []exportColumn{
+	{"id", "students.id"},
+	{"roll_number", "students.roll_number"},
+	{"first_name", "students.first_name"},
+	{"middle_name", "students.middle_name"},
+	{"last_name", "students.last_name"},
+	{"birth_date", "students.birth_date"},
+	{"adhar_card", "students.adhar_card"},
+	{"parent_name", "students.parent_name"},
+	{"parent_occupation", "students.parent_occupation"},
+	{"contact_number", "students.contact_number"},
+	{"wh_number", "students.wh_number"},
+	{"town", "students.town"},
+	{"blood_group", "students.blood_group"},
+	{"emergency_contact", "students.emergency_contact"},
+	{"status", "coalesce(nullif(students.status, ''), 'Enquiry')"},
+	{"balance", "students.balance"},
+	{"student_account_balance", "students.student_account_balance"},
+	{"admitted_on", "students.created_at"},
+	{"batch", "batches.name"},
+	{"standard", "standards.name"},
+	{"fee", "batch_standard_students.fee"},
+	{"hostel", "hostels.name"},
+	{"hostel_room", "hostel_rooms.name"},
+	{"fee_included", "hostel_students.fee_included"},
+	{"tags", "(select group_concat(tags.name, ', ') from student_tags join tags on tags.id = student_tags.tag_id where student_tags.student_id = students.id)"},
+}
+
+// studentExportColumnsWithCustom adds a column for each custom field after
+// the student columns.
+func studentExportColumnsWithCustom() ([]exportColumn, error) {
+	customFields, err := (&CustomField{}).All()
+	if err != nil {
+		return nil, err
+	}
+	columns := append([]exportColumn{}, studentExportColumns...)
+	for _, customField := range customFields {
+		columns = append(columns, exportColumn{customField.Key, "json_extract(students.custom_fields, '" + customField.path() + "')"})
+	}
+	return columns, nil
+}
+
+func studentExportColumn(name string) bool {
+	for _, column := range studentExportColumns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportStudents streams the filtered students to w, one row per student
+// with the chosen columns (all of them when none are given).
+func ExportStudents(filter StudentExportFilter, columns []string, w sheet.Writer) error {
+	exportColumns, err := studentExportColumnsWithCustom()
+	if err != nil {
+		return err
+	}
+	selects, err := exportSelects(exportColumns, columns)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		for _, column := range exportColumns {
+			columns = append(columns, column.Name)
+		}
+	}
+
+	query := db.Driver.Table("students").Select(strings.Join(selects, ", ")).
+		Joins("left join batch_standard_students on batch_standard_students.student_id = students.id and batch_standard_students.deleted_at is null").
+		Joins("left join batches on batches.id = batch_standard_students.batch_id").
+		Joins("left join standards on standards.id = batch_standard_students.standard_id").
+		Joins("left join hostel_students on hostel_students.student_id = students.id and hostel_students.deleted_at is null").
+		Joins("left join hostels on hostels.id = hostel_students.hostel_id").
+		Joins("left join hostel_rooms on hostel_rooms.id = hostel_students.hostel_room_id").
+		Where("students.deleted_at is null")
+
+	if filter.BatchId > 0 {
+		query = query.Where("batch_standard_students.batch_id = ?", filter.BatchId)
+	}
+	if filter.StandardId > 0 {
+		query = query.Where("batch_standard_students.standard_id = ?", filter.StandardId)
+	}
+	if filter.HostelId > 0 {
+		query = query.Where("hostel_students.hostel_id = ?", filter.HostelId)
+	}
+	if filter.Status != "" {
+		query = query.Where("coalesce(nullif(students.status, ''), 'Enquiry') = ?", filter.Status)
+	}
+	if filter.Town != "" {
+		query = query.Where("lower(students.town) = lower(?)", filter.Town)
+	}
+	if filter.MinBalance != nil {
+		query = query.Where("students.balance >= ?", *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		query = query.Where("students.balance <= ?", *filter.MaxBalance)
+	}
+	query = taggedStudents(query, filter.Tags)
+	if filter.Segment != nil {
+		ids, err := filter.Segment.studentIds()
+		if err != nil {
+			return err
+		}
+		query = query.Where("students.id in (?)", ids)
+	}
+
+	rows, err := query.Order("students.id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := w.WriteRow(columns); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, value := range values {
+			record[i] = exportValue(value)
+			if columns[i] == "adhar_card" {
+				record[i] = MaskAadhaar(record[i])
+			}
+		}
+		if err := w.WriteRow(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func ValidateStudentExportColumns(columns []string) error {
+	exportColumns, err := studentExportColumnsWithCustom()
+	if err != nil {
+		return err
+	}
+	_, err = exportSelects(exportColumns, columns)
+	return err
+}
+
+func exportSelects(exportColumns []exportColumn, columns []string) ([]string, error) {
+	selects := []string{}
+	if len(columns) == 0 {
+		for _, column := range exportColumns {
+			selects = append(selects, column.Select)
+		}
+		return selects, nil
+	}
+
+	for _, name := range columns {
+		found := false
+		for _, column := range exportColumns {
+			if column.Name == name {
+				selects = append(selects, column.Select)
+				found = true
+			}
+		}
+		if !found {
+			return nil, swapErr.ErrBadData
+		}
+	}
+	return selects, nil
+}
+
+func exportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
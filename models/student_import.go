@@ -0,0 +1,295 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+var studentImportFields = []string{"first_name", "middle_name", "last_name", "birth_date", "adhar_card", "parent_name",
+	"parent_occupation", "contact_number", "wh_number", "town", "blood_group", "emergency_contact", "status",
+	"batch_standard_id", "hostel_id", "hostel_room_id", "fee_included"}
+
+type StudentImportOptions struct {
+	// Mapping maps a column header of the file to a student field. Columns
+	// left out are matched by name, "First Name" to first_name.
+	Mapping 				map[string]string
+	BatchStandardId uint
+	HostelId 				uint
+	HostelRoomId 		uint
+	FeeIncluded 		bool
+	AllOrNothing 		bool
+	DryRun 					bool
+}
+
+type StudentImportRow struct {
+	Row 			int `json:"row"`
+	Name 			string `json:"name"`
+	StudentId uint `json:"student_id,omitempty"`
+	Errors 		map[string][]string `json:"errors,omitempty"`
+	Duplicates []DuplicateCandidate `json:"duplicates,omitempty"`
+	student 	*Student
+	batchStandard *BatchStandard
+	hostel 		*Hostel
+	hostelRoom *HostelRoom
+	feeIncluded bool
+}
+
+type StudentImportReport struct {
+	Rows 			[]StudentImportRow `json:"rows"`
+	Valid 		int `json:"valid"`
+	Invalid 	int `json:"invalid"`
+	Imported 	int `json:"imported"`
+	Committed bool `json:"committed"`
+	RolledBack bool `json:"rolled_back"`
+}
+
+// ImportStudents validates every row of the sheet (header first) and, unless
+// it is a dry run, creates the valid students, enrolling them into the batch
+// standard and hostel room given by the row or the options. The rows are
+// saved in one transaction; a row that fails is rolled back on its own, or
+// with AllOrNothing the whole import is.
+func ImportStudents(rows [][]string, options StudentImportOptions) (*StudentImportReport, error) {
+	report := &StudentImportReport{Rows: []StudentImportRow{}}
+	if len(rows) < 2 {
+		return report, swapErr.ErrBadData
+	}
+	customFields, err := (&CustomField{}).All()
+	if err != nil {
+		return report, err
+	}
+	fields := importColumns(rows[0], options.Mapping, customFields)
+
+	occupancy := map[uint]int64{}
+	for i, values := range rows[1:] {
+		data := importRowData(fields, values)
+		if len(data) == 0 {
+			continue
+		}
+		row := StudentImportRow{Row: i + 2, Errors: map[string][]string{}}
+		row.validate(data, options, occupancy)
+		if len(row.Errors) > 0 {
+			report.Invalid = report.Invalid + 1
+		} else {
+			row.Errors = nil
+			report.Valid = report.Valid + 1
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if options.DryRun || report.Valid == 0 || (options.AllOrNothing && report.Invalid > 0) {
+		return report, nil
+	}
+
+	failed := false
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		for i := range report.Rows {
+			row := &report.Rows[i]
+			if row.Errors != nil {
+				continue
+			}
+			err := tx.Transaction(func(rowTx *gorm.DB) error {
+				return row.commit(rowTx)
+			})
+			if err == nil {
+				report.Imported = report.Imported + 1
+				continue
+			}
+
+			row.StudentId = 0
+			row.Errors = map[string][]string{"Row": {err.Error()}}
+			report.Valid = report.Valid - 1
+			report.Invalid = report.Invalid + 1
+			if options.AllOrNothing {
+				failed = true
+				return err
+			}
+		}
+		return nil
+	})
+	if failed {
+		for i := range report.Rows {
+			report.Rows[i].StudentId = 0
+		}
+		report.Imported = 0
+		report.RolledBack = true
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+
+	for _, row := range report.Rows {
+		if row.StudentId > 0 {
+			saveStudentBalance(row.StudentId)
+		}
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// importColumns names the student field of each column. Custom fields are
+// matched by key and named "custom_fields.<key>".
+func importColumns(header []string, mapping map[string]string, customFields []CustomField) []string {
+	custom := map[string]bool{}
+	for _, customField := range customFields {
+		custom[customField.Key] = true
+	}
+	fields := make([]string, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		name := strings.ToLower(strings.Join(strings.Fields(column), "_"))
+		if field, ok := mapping[column]; ok {
+			fields[i], name = field, field
+		} else {
+			for _, field := range studentImportFields {
+				if field == name {
+					fields[i] = field
+				}
+			}
+		}
+		if custom[name] {
+			fields[i] = "custom_fields." + name
+		}
+	}
+	return fields
+}
+
+func importRowData(fields []string, values []string) map[string]interface{} {
+	data := map[string]interface{}{}
+	customFields := map[string]interface{}{}
+	for i, value := range values {
+		value = strings.TrimSpace(value)
+		if i >= len(fields) || fields[i] == "" || value == "" {
+			continue
+		}
+		if key := strings.TrimPrefix(fields[i], "custom_fields."); key != fields[i] {
+			customFields[key] = value
+			continue
+		}
+		data[fields[i]] = value
+	}
+	if len(customFields) > 0 {
+		data["custom_fields"] = customFields
+	}
+	return data
+}
+
+func (row *StudentImportRow) addError(field string, err error) {
+	row.Errors[field] = append(row.Errors[field], err.Error())
+}
+
+func (row *StudentImportRow) validate(data map[string]interface{}, options StudentImportOptions, occupancy map[uint]int64) {
+	if birthDate, ok := data["birth_date"]; ok {
+		date, err := time.Parse("2006-01-02", birthDate.(string))
+		if err != nil {
+			row.addError("BirthDate", swapErr.ErrBadData)
+		}
+		data["birth_date"] = date.Format("2006-01-02T15:04:05Z")
+	}
+
+	row.student = NewStudent(data)
+	row.Name = strings.TrimSpace(row.student.FirstName + " " + row.student.LastName)
+	if err := row.student.Validate(); err != nil {
+		if errs, ok := err.(validator.ErrorMap); ok {
+			for field, fieldErrs := range errs {
+				for _, fieldErr := range fieldErrs {
+					row.addError(field, fieldErr)
+				}
+			}
+		} else {
+			row.addError("Row", err)
+		}
+	}
+	// possible duplicates only warn, the row is still imported
+	row.Duplicates, _ = row.student.FindDuplicates()
+
+	batchStandardId, err := importId(data, "batch_standard_id", options.BatchStandardId)
+	if err != nil {
+		row.addError("BatchStandardId", err)
+	}
+	if batchStandardId > 0 {
+		row.batchStandard = &BatchStandard{ID: batchStandardId}
+		if err := row.batchStandard.Find(); err != nil {
+			row.addError("BatchStandardId", err)
+		}
+	}
+
+	hostelId, err := importId(data, "hostel_id", options.HostelId)
+	if err != nil {
+		row.addError("HostelId", err)
+	}
+	hostelRoomId, err := importId(data, "hostel_room_id", options.HostelRoomId)
+	if err != nil {
+		row.addError("HostelRoomId", err)
+	}
+	row.feeIncluded = options.FeeIncluded
+	if feeIncluded, ok := data["fee_included"]; ok {
+		row.feeIncluded, _ = strconv.ParseBool(feeIncluded.(string))
+	}
+	if (hostelId == 0 && hostelRoomId == 0) || row.Errors["HostelId"] != nil || row.Errors["HostelRoomId"] != nil {
+		return
+	}
+
+	row.hostel = &Hostel{ID: hostelId}
+	row.hostelRoom = &HostelRoom{ID: hostelRoomId}
+	if err := row.hostel.Find(); err != nil {
+		row.addError("HostelId", err)
+		return
+	}
+	if err := row.hostelRoom.Find(); err != nil {
+		row.addError("HostelRoomId", err)
+		return
+	}
+	if row.hostelRoom.HostelID != row.hostel.ID {
+		row.addError("HostelRoomId", swapErr.ErrRoomNotInHostel)
+		return
+	}
+	if _, ok := occupancy[row.hostelRoom.ID]; !ok {
+		occupancy[row.hostelRoom.ID] = row.hostelRoom.HostelStudentsCount
+	}
+	if row.hostelRoom.NoOfStudents > 0 && occupancy[row.hostelRoom.ID] >= int64(row.hostelRoom.NoOfStudents) {
+		row.addError("HostelRoomId", swapErr.ErrRoomFull)
+		return
+	}
+	occupancy[row.hostelRoom.ID] = occupancy[row.hostelRoom.ID] + 1
+}
+
+// importId reads an id cell of the row, falling back to the id given for
+// the whole import when the cell is missing or blank. A cell that is not a
+// positive number is an error rather than no id.
+func importId(data map[string]interface{}, field string, fallback uint) (uint, error) {
+	value, ok := data[field]
+	if !ok {
+		return fallback, nil
+	}
+	id, err := strconv.Atoi(value.(string))
+	if err != nil || id <= 0 {
+		return 0, swapErr.ErrBadData
+	}
+	return uint(id), nil
+}
+
+// commit saves the student of the row through tx, with its enrollment and
+// hostel stay.
+func (row *StudentImportRow) commit(tx *gorm.DB) error {
+	if err := row.student.create(tx); err != nil {
+		return err
+	}
+	if row.batchStandard != nil {
+		if err := row.student.assignBatchStandard(tx, row.batchStandard); err != nil {
+			return err
+		}
+	}
+	if row.hostel != nil {
+		if err := row.student.assignHostel(tx, row.hostel, row.hostelRoom, row.feeIncluded); err != nil {
+			return err
+		}
+	}
+	row.StudentId = row.student.ID
+	return nil
+}
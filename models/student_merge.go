@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+)
+
+// StudentMerge logs a duplicate student merged into the surviving one and
+// how many rows of each kind were moved.
+type StudentMerge struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" gorm:"index"`
+	DuplicateId 		uint `json:"duplicate_id"`
+	DuplicateName 	string `json:"duplicate_name"`
+	Reason 					string `json:"reason"`
+	Moved 					string `json:"moved"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+}
+
+// studentMergeTables are the tables whose rows follow the student on merge.
+var studentMergeTables = []interface{}{&Transaction{}, &StudentAccount{}, &BatchStandardStudent{}, &HostelStudent{},
+	&HostelCharge{}, &HostelTransfer{}, &Deposit{}, &WriteOff{}, &PosSale{}, &StudentStatusChange{},
+	&StudentDocument{}, &AadhaarAccessLog{}, &BatchStandardTransfer{}, &StudentNote{}, &Certificate{}}
+
+func migrateStudentMerge() {
+	fmt.Println("migrating student merge..")
+	err := db.Driver.AutoMigrate(&StudentMerge{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// Merge moves everything of duplicate to s, recomputes the balances and
+// deletes duplicate. The two cannot both be in a class or a hostel, and the
+// duplicate cannot have transactions in a closed fiscal year, as those are
+// read-only.
+func (s *Student) Merge(duplicate *Student, reason string, userId int) (*StudentMerge, error) {
+	if s.ID == duplicate.ID {
+		return nil, swapErr.ErrMergeSameStudent
+	}
+	for _, model := range []interface{}{&BatchStandardStudent{}, &HostelStudent{}} {
+		var count int64
+		db.Driver.Model(model).Where("student_id in (?)", []uint{s.ID, duplicate.ID}).Group("student_id").Count(&count)
+		if count > 1 {
+			return nil, swapErr.ErrMergeConflict
+		}
+	}
+	closed, err := hasClosedYearTransactions(db.Driver, duplicate.ID)
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		return nil, swapErr.ErrFiscalYearClosed
+	}
+
+	merge := &StudentMerge{StudentId: s.ID, DuplicateId: duplicate.ID, Reason: reason, UserID: userId,
+		DuplicateName: duplicateName(*duplicate)}
+	moved := map[string]int64{}
+	err = db.Driver.Transaction(func(tx *gorm.DB) error {
+		for _, model := range studentMergeTables {
+			statement := &gorm.Statement{DB: tx}
+			if err := statement.Parse(model); err != nil {
+				return err
+			}
+			result := tx.Unscoped().Model(model).Where("student_id = ?", duplicate.ID).Update("student_id", s.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			moved[statement.Schema.Table] = result.RowsAffected
+		}
+
+		count, err := mergeFiscalYearBalances(tx, s.ID, duplicate.ID)
+		if err != nil {
+			return err
+		}
+		moved["fiscal_year_balances"] = count
+
+		// guardians shared by both stay linked once, as a non primary link
+		err = tx.Where("student_id = ? and guardian_id in (?)", duplicate.ID,
+			tx.Model(&StudentGuardian{}).Select("guardian_id").Where("student_id = ?", s.ID)).Delete(&StudentGuardian{}).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&StudentGuardian{}).Where("student_id = ?", duplicate.ID).
+			Updates(map[string]interface{}{"student_id": s.ID, "is_primary": false})
+		if result.Error != nil {
+			return result.Error
+		}
+		moved["student_guardians"] = result.RowsAffected
+
+		count, err = mergeStudentTags(tx, s.ID, duplicate.ID)
+		if err != nil {
+			return err
+		}
+		moved["student_tags"] = count
+
+		if moved["hostel_students"] > 0 {
+			s.HasHostel = true
+		}
+		if s.RollNumber == "" {
+			s.RollNumber = duplicate.RollNumber
+		}
+		if err := tx.Model(&Student{}).Where("id = ?", s.ID).
+			Updates(map[string]interface{}{"has_hostel": s.HasHostel, "roll_number": s.RollNumber}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&Student{}, duplicate.ID).Error; err != nil {
+			return err
+		}
+		if err := unindexStudent(tx, duplicate.ID); err != nil {
+			return err
+		}
+
+		movedJSON, _ := json.Marshal(moved)
+		merge.Moved = string(movedJSON)
+		return tx.Create(merge).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Find(); err != nil {
+		return merge, err
+	}
+	if err := s.SaveBalance(); err != nil {
+		return merge, err
+	}
+	return merge, s.SaveStudentAccountBalance()
+}
+
+func (s *Student) GetMerges() ([]StudentMerge, error) {
+	var merges []StudentMerge
+	err := db.Driver.Where("student_id = ?", s.ID).Order("id desc").Find(&merges).Error
+	return merges, err
+}
+
+// mergeFiscalYearBalances adds the duplicate's closed year balances to the
+// survivor's for the same year and moves the rest.
+func mergeFiscalYearBalances(tx *gorm.DB, studentId uint, duplicateId uint) (int64, error) {
+	var balances []FiscalYearBalance
+	if err := tx.Where("student_id = ?", duplicateId).Find(&balances).Error; err != nil {
+		return 0, err
+	}
+	for _, balance := range balances {
+		survivor := FiscalYearBalance{}
+		result := tx.Where("student_id = ? and fiscal_year_id = ?", studentId, balance.FiscalYearId).Limit(1).Find(&survivor)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.Model(&balance).Update("student_id", studentId).Error; err != nil {
+				return 0, err
+			}
+			continue
+		}
+		err := tx.Model(&survivor).Updates(map[string]interface{}{
+			"opening_balance": survivor.OpeningBalance + balance.OpeningBalance,
+			"debits": survivor.Debits + balance.Debits,
+			"credits": survivor.Credits + balance.Credits,
+			"closing_balance": survivor.ClosingBalance + balance.ClosingBalance}).Error
+		if err == nil {
+			err = tx.Delete(&balance).Error
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(balances)), nil
+}
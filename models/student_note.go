@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"swapnil-ex/models/db"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+type StudentNote struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" validate:"nonzero" gorm:"index"`
+	Note 						string `json:"note" validate:"nonzero"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+func migrateStudentNote() {
+	fmt.Println("migrating student note..")
+	err := db.Driver.AutoMigrate(&StudentNote{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func (sn *StudentNote) Validate() error {
+	if errs := validator.Validate(sn); errs != nil {
+		return errs
+	} else {
+		return nil
+	}
+}
+
+func (sn *StudentNote) Assign(studentNoteData map[string]interface{}) {
+	if note, ok := studentNoteData["note"]; ok {
+		sn.Note, _ = note.(string)
+	}
+}
+
+func (sn *StudentNote) All(studentId uint) ([]StudentNote, error) {
+	var notes []StudentNote
+	err := db.Driver.Where("student_id = ?", studentId).Order("id desc").Find(&notes).Error
+	return notes, err
+}
+
+func (sn *StudentNote) Find() error {
+	err := db.Driver.First(sn, "ID = ?", sn.ID).Error
+	return err
+}
+
+func (sn *StudentNote) Create() error {
+	err := db.Driver.Create(sn).Error
+	return err
+}
+
+func (sn *StudentNote) Delete() error {
+	err := db.Driver.Delete(sn).Error
+	return err
+}
@@ -0,0 +1,286 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"swapnil-ex/models/db"
+	"unicode"
+	"gorm.io/gorm"
+)
+
+// fuzzySearchSimilarity is how close every searched word has to be to a word
+// of the student in typo tolerant mode.
+const fuzzySearchSimilarity = 0.75
+
+// studentSearchEnabled is set when SQLite has FTS5, which mattn/go-sqlite3
+// only builds with the sqlite_fts5 tag. Without it search falls back to LIKE.
+var studentSearchEnabled bool
+
+// migrateStudentSearch creates the full text index of students: student_search
+// for ranked word and prefix matches, with a phonetic key per word so
+// different spellings and Devanagari names meet, and student_search_trigram
+// for the typo tolerant mode. The index is rebuilt when it is out of step
+// with the students table.
+func migrateStudentSearch() {
+	fmt.Println("migrating student search..")
+	err := db.Driver.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS student_search USING fts5(name, parent_name, town, contact, roll_number, phonetic, tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3')").Error
+	if err == nil {
+		err = db.Driver.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS student_search_trigram USING fts5(terms, tokenize = 'trigram')").Error
+	}
+	if err != nil {
+		fmt.Println("student search index disabled, build with -tags sqlite_fts5", err)
+		return
+	}
+	studentSearchEnabled = true
+
+	var students, indexed, trigrams int64
+	db.Driver.Model(&Student{}).Count(&students)
+	db.Driver.Raw("SELECT count(*) FROM student_search").Scan(&indexed)
+	db.Driver.Raw("SELECT count(*) FROM student_search_trigram").Scan(&trigrams)
+	if students != indexed || students != trigrams {
+		if err := RebuildStudentSearch(); err != nil {
+			panic("failed to migrate database")
+		}
+	}
+}
+
+func RebuildStudentSearch() error {
+	if !studentSearchEnabled {
+		return nil
+	}
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM student_search").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM student_search_trigram").Error; err != nil {
+			return err
+		}
+		var students []Student
+		if err := tx.Find(&students).Error; err != nil {
+			return err
+		}
+		for _, student := range students {
+			if err := insertStudentSearch(tx, student); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// indexStudent brings the search rows of a student in line with the table,
+// dropping them once the student is deleted.
+func indexStudent(tx *gorm.DB, studentId uint) error {
+	if !studentSearchEnabled {
+		return nil
+	}
+	if err := unindexStudent(tx, studentId); err != nil {
+		return err
+	}
+	var students []Student
+	if err := tx.Where("id = ?", studentId).Limit(1).Find(&students).Error; err != nil || len(students) == 0 {
+		return err
+	}
+	return insertStudentSearch(tx, students[0])
+}
+
+func unindexStudent(tx *gorm.DB, studentId uint) error {
+	if !studentSearchEnabled {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM student_search WHERE rowid = ?", studentId).Error; err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM student_search_trigram WHERE rowid = ?", studentId).Error
+}
+
+func insertStudentSearch(tx *gorm.DB, s Student) error {
+	name := strings.Join(strings.Fields(s.FirstName + " " + s.MiddleName + " " + s.LastName), " ")
+	contact := strings.TrimSpace(s.ContactNumber + " " + s.WhNumber)
+	phonetic := phoneticKeys(name + " " + s.ParentName + " " + s.Town)
+	err := tx.Exec("INSERT INTO student_search(rowid, name, parent_name, town, contact, roll_number, phonetic) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		s.ID, name, s.ParentName, s.Town, contact, s.RollNumber, phonetic).Error
+	if err != nil {
+		return err
+	}
+	terms := strings.ToLower(strings.Join([]string{name, s.ParentName, s.Town, contact, s.RollNumber, phonetic}, " "))
+	return tx.Exec("INSERT INTO student_search_trigram(rowid, terms) VALUES (?, ?)", s.ID, terms).Error
+}
+
+// searchStudentIds returns the ids of the students matching search, best
+// match first. Every word has to match a word of the student by prefix,
+// either as written or by its phonetic key; with fuzzy, words may also be
+// misspelt.
+func searchStudentIds(search string, fuzzy bool) ([]uint, error) {
+	words := searchWords(search)
+	if len(words) == 0 {
+		return []uint{}, nil
+	}
+	if fuzzy {
+		return fuzzySearchStudentIds(words)
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = fmt.Sprintf("{name parent_name town contact roll_number} : %s*", ftsQuote(word))
+		if key := phoneticKey(word); key != "" {
+			terms[i] = fmt.Sprintf("(%s OR phonetic : %s*)", terms[i], ftsQuote(key))
+		}
+	}
+	var ids []uint
+	err := db.Driver.Raw("SELECT rowid FROM student_search WHERE student_search MATCH ? ORDER BY bm25(student_search, 10.0, 4.0, 2.0, 4.0, 8.0, 1.0)",
+		strings.Join(terms, " AND ")).Scan(&ids).Error
+	return ids, err
+}
+
+// fuzzySearchStudentIds looks up students sharing a trigram with any word
+// and keeps those where each word is close enough to one of their words.
+func fuzzySearchStudentIds(words []string) ([]uint, error) {
+	trigrams := []string{}
+	for _, word := range append(words, phoneticKeys(strings.Join(words, " "))) {
+		runes := []rune(word)
+		for i := 0; i + 3 <= len(runes); i++ {
+			trigrams = append(trigrams, ftsQuote(string(runes[i:i + 3])))
+		}
+	}
+	if len(trigrams) == 0 {
+		return searchStudentIds(strings.Join(words, " "), false)
+	}
+
+	type candidate struct {
+		ID    uint
+		Terms string
+		score float64
+	}
+	var candidates []candidate
+	err := db.Driver.Raw("SELECT rowid AS id, terms FROM student_search_trigram WHERE student_search_trigram MATCH ? ORDER BY rank LIMIT 500",
+		strings.Join(trigrams, " OR ")).Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []candidate{}
+	for _, c := range candidates {
+		studentWords := strings.Fields(c.Terms)
+		total := 0.0
+		for _, word := range words {
+			best := 0.0
+			for _, studentWord := range studentWords {
+				similarity := nameSimilarity(word, studentWord)
+				if key := phoneticKey(word); key != "" && nameSimilarity(key, studentWord) > similarity {
+					similarity = nameSimilarity(key, studentWord)
+				}
+				if strings.Contains(studentWord, word) {
+					similarity = 1.0
+				}
+				if similarity > best {
+					best = similarity
+				}
+			}
+			if best < fuzzySearchSimilarity {
+				total = -1.0
+				break
+			}
+			total = total + best
+		}
+		if total > 0.0 {
+			c.score = total
+			matches = append(matches, c)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	ids := make([]uint, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
+	return ids, nil
+}
+
+func searchWords(search string) []string {
+	return strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	})
+}
+
+func ftsQuote(term string) string {
+	return "\"" + strings.ReplaceAll(term, "\"", "\"\"") + "\""
+}
+
+func phoneticKeys(text string) string {
+	keys := []string{}
+	for _, word := range searchWords(text) {
+		if key := phoneticKey(word); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return strings.Join(keys, " ")
+}
+
+// phoneticKey folds the spellings of a Marathi name written in Latin or
+// Devanagari to one key: aspirates and sibilants are merged, long vowels
+// shortened, doubled letters collapsed and the short a, which transliterations
+// add or leave out freely, dropped after the first letter.
+func phoneticKey(word string) string {
+	word = strings.ToLower(transliterateDevanagari(word))
+	for _, fold := range [][2]string{{"chh", "c"}, {"ch", "c"}, {"sh", "s"}, {"ph", "f"}, {"kh", "k"}, {"gh", "g"},
+		{"th", "t"}, {"dh", "d"}, {"bh", "b"}, {"jh", "j"}, {"ck", "k"}, {"w", "v"}, {"z", "j"}, {"q", "k"}, {"x", "ks"},
+		{"ee", "i"}, {"ii", "i"}, {"oo", "u"}, {"uu", "u"}, {"aa", "a"}} {
+		word = strings.ReplaceAll(word, fold[0], fold[1])
+	}
+
+	key := []rune{}
+	for i, r := range word {
+		if r < 'a' || r > 'z' {
+			if unicode.IsDigit(r) {
+				key = append(key, r)
+			}
+			continue
+		}
+		if r == 'a' && i > 0 {
+			continue
+		}
+		if len(key) > 0 && key[len(key) - 1] == r {
+			continue
+		}
+		key = append(key, r)
+	}
+	return string(key)
+}
+
+var devanagariConsonants = map[rune]string{'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "n", 'च': "ch", 'छ': "chh",
+	'ज': "j", 'झ': "jh", 'ञ': "n", 'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n", 'त': "t", 'थ': "th", 'द': "d",
+	'ध': "dh", 'न': "n", 'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh", 'म': "m", 'य': "y", 'र': "r", 'ल': "l", 'ळ': "l",
+	'व': "v", 'श': "sh", 'ष': "sh", 'स': "s", 'ह': "h"}
+
+var devanagariVowels = map[rune]string{'अ': "a", 'आ': "aa", 'इ': "i", 'ई': "ii", 'उ': "u", 'ऊ': "uu", 'ऋ': "ru",
+	'ए': "e", 'ऐ': "ai", 'ओ': "o", 'औ': "au", 'ा': "aa", 'ि': "i", 'ी': "ii", 'ु': "u", 'ू': "uu", 'ृ': "ru",
+	'े': "e", 'ै': "ai", 'ो': "o", 'ौ': "au", 'ं': "n", 'ँ': "n", 'ः': "h"}
+
+func transliterateDevanagari(word string) string {
+	var latin strings.Builder
+	runes := []rune(strings.ReplaceAll(word, "़", ""))
+	for i, r := range runes {
+		if consonant, ok := devanagariConsonants[r]; ok {
+			latin.WriteString(consonant)
+			// the inherent a, unless a vowel sign or virama follows
+			if i + 1 >= len(runes) || (runes[i + 1] != '्' && !isDevanagariSign(runes[i + 1])) {
+				latin.WriteString("a")
+			}
+		} else if vowel, ok := devanagariVowels[r]; ok {
+			latin.WriteString(vowel)
+		} else if r == '्' {
+			continue
+		} else {
+			latin.WriteRune(r)
+		}
+	}
+	return latin.String()
+}
+
+func isDevanagariSign(r rune) bool {
+	return r >= 'ा' && r <= 'ौ'
+}
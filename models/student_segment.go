@@ -0,0 +1,270 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+// StudentSegment is a saved student list. Query is the query string of the
+// list, as sent to GET /students: filter[...], search, fuzzy, tags and sort.
+// It picks the students of exports, contact lists and reports.
+type StudentSegment struct {
+	ID            	uint `json:"id"`
+	Name 						string `json:"name" validate:"nonzero"`
+	Description 		string `json:"description"`
+	Query 					string `json:"query"`
+	UserID 					int `json:"user_id"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+// SegmentContact is who to reach about a student of a segment: the student
+// and the primary guardian, when there is one.
+type SegmentContact struct {
+	StudentId 				uint `json:"student_id"`
+	Name 							string `json:"name"`
+	ContactNumber 		string `json:"contact_number"`
+	WhNumber 					string `json:"wh_number"`
+	GuardianName 			string `json:"guardian_name"`
+	GuardianPhone 		string `json:"guardian_phone"`
+	GuardianWhNumber 	string `json:"guardian_wh_number"`
+}
+
+// SegmentReport sums up the students of a segment. Dues add up the negative
+// balances; ByStandard counts the students by the class they are in now.
+type SegmentReport struct {
+	Students 							int64 `json:"students"`
+	ByStatus 							map[string]int64 `json:"by_status"`
+	ByStandard 						map[string]int64 `json:"by_standard"`
+	HostelStudents 				int64 `json:"hostel_students"`
+	StudentsWithDues 			int64 `json:"students_with_dues"`
+	Dues 									float64 `json:"dues"`
+	Balance 							float64 `json:"balance"`
+	StudentAccountBalance float64 `json:"student_account_balance"`
+}
+
+func migrateStudentSegment() {
+	fmt.Println("migrating student segment..")
+	err := db.Driver.AutoMigrate(&StudentSegment{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func NewStudentSegment(segmentData map[string]interface{}) *StudentSegment {
+	segment := &StudentSegment{}
+	segment.Assign(segmentData)
+	return segment
+}
+
+// StudentListParams reads the search, fuzzy and tags params of a student
+// list. Tags are comma separated and may be repeated; students need all.
+func StudentListParams(values url.Values) (string, bool, []string) {
+	fuzzy, _ := strconv.ParseBool(values.Get("fuzzy"))
+	tags := []string{}
+	for _, value := range values["tags"] {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	return values.Get("search"), fuzzy, TagNames(tags)
+}
+
+func (ss *StudentSegment) Validate() error {
+	errs := validator.ErrorMap{}
+	if err := validator.Validate(ss); err != nil {
+		errs = err.(validator.ErrorMap)
+	}
+	if _, err := ss.ListQuery(nil, 0); err != nil {
+		errs["Query"] = append(errs["Query"], validator.TextErr{Err: err})
+	}
+	var taken int64
+	if err := db.Driver.Model(&StudentSegment{}).Where("name = ? and id <> ?", ss.Name, ss.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		errs["Name"] = append(errs["Name"], validator.TextErr{Err: swapErr.ErrSegmentExists})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (ss *StudentSegment) Assign(segmentData map[string]interface{}) {
+	if name, ok := segmentData["name"].(string); ok {
+		ss.Name = strings.TrimSpace(name)
+	}
+	if description, ok := segmentData["description"].(string); ok {
+		ss.Description = description
+	}
+	if query, ok := segmentData["query"].(string); ok {
+		ss.Query = strings.TrimPrefix(strings.TrimSpace(query), "?")
+	}
+}
+
+func (ss *StudentSegment) All() ([]StudentSegment, error) {
+	segments := []StudentSegment{}
+	err := db.Driver.Order("name").Find(&segments).Error
+	return segments, err
+}
+
+func (ss *StudentSegment) Find() error {
+	err := db.Driver.First(ss, "ID = ?", ss.ID).Error
+	return err
+}
+
+func (ss *StudentSegment) Create() error {
+	err := db.Driver.Create(ss).Error
+	return err
+}
+
+func (ss *StudentSegment) Update() error {
+	err := db.Driver.Save(ss).Error
+	return err
+}
+
+func (ss *StudentSegment) Delete() error {
+	err := db.Driver.Delete(ss).Error
+	return err
+}
+
+// ListQuery reads the list query of the segment. Paging, sorting and the
+// fields to pick are taken from params over the saved ones.
+func (ss *StudentSegment) ListQuery(params url.Values, defaultPageSize int) (*ListQuery, error) {
+	values, err := url.ParseQuery(ss.Query)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"page", "page_size", "sort", "fields"} {
+		if value := params.Get(name); value != "" {
+			values.Set(name, value)
+		}
+	}
+	fields, err := StudentListFieldsWithCustom()
+	if err != nil {
+		return nil, err
+	}
+	return ParseListQuery(values, fields, "", defaultPageSize)
+}
+
+// Students lists a page of the students of the segment, with their total.
+func (ss *StudentSegment) Students(lq *ListQuery) ([]Student, int64, error) {
+	values, _ := url.ParseQuery(ss.Query)
+	search, fuzzy, tags := StudentListParams(values)
+	s := &Student{}
+	students, err := s.All(lq, search, fuzzy, tags)
+	if err != nil {
+		return students, 0, err
+	}
+	total, err := s.Count(lq, search, fuzzy, tags)
+	return students, total, err
+}
+
+// studentIds selects the ids of every student of the segment, to be used as
+// a subquery.
+func (ss *StudentSegment) studentIds() (*gorm.DB, error) {
+	lq, err := ss.ListQuery(nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	values, _ := url.ParseQuery(ss.Query)
+	search, fuzzy, tags := StudentListParams(values)
+	query, _, err := studentListQuery(lq, search, fuzzy, tags)
+	if err != nil {
+		return nil, err
+	}
+	return query.Select("students.id"), nil
+}
+
+// StudentIds lists the ids of every student of the segment.
+func (ss *StudentSegment) StudentIds() ([]uint, error) {
+	ids := []uint{}
+	query, err := ss.studentIds()
+	if err != nil {
+		return ids, err
+	}
+	err = query.Pluck("students.id", &ids).Error
+	return ids, err
+}
+
+// Contacts lists who to reach for the students of the segment, by name.
+func (ss *StudentSegment) Contacts() ([]SegmentContact, error) {
+	contacts := []SegmentContact{}
+	ids, err := ss.studentIds()
+	if err != nil {
+		return contacts, err
+	}
+	err = db.Driver.Table("students").
+		Select("students.id as student_id, trim(replace(students.first_name || ' ' || coalesce(students.middle_name, '') || ' ' || students.last_name, '  ', ' ')) as name, " +
+			"students.contact_number, students.wh_number, guardians.name as guardian_name, guardians.phone as guardian_phone, guardians.wh_number as guardian_wh_number").
+		Joins("left join student_guardians on student_guardians.student_id = students.id and student_guardians.is_primary = ?", true).
+		Joins("left join guardians on guardians.id = student_guardians.guardian_id and guardians.deleted_at is null").
+		Where("students.deleted_at is null and students.id in (?)", ids).
+		Order("students.first_name, students.last_name, students.id").Scan(&contacts).Error
+	return contacts, err
+}
+
+// Report sums up the students of the segment.
+func (ss *StudentSegment) Report() (*SegmentReport, error) {
+	report := &SegmentReport{ByStatus: map[string]int64{}, ByStandard: map[string]int64{}}
+	ids, err := ss.studentIds()
+	if err != nil {
+		return nil, err
+	}
+	students := func() *gorm.DB {
+		return db.Driver.Table("students").Where("students.deleted_at is null and students.id in (?)", ids)
+	}
+
+	var totals struct {
+		Students 							int64
+		HostelStudents 				int64
+		StudentsWithDues 			int64
+		Dues 									float64
+		Balance 							float64
+		StudentAccountBalance float64
+	}
+	err = students().Select("count(*) as students, coalesce(sum(case when students.has_hostel then 1 else 0 end), 0) as hostel_students, " +
+		"coalesce(sum(case when students.balance < 0 then 1 else 0 end), 0) as students_with_dues, " +
+		"coalesce(sum(case when students.balance < 0 then -students.balance else 0 end), 0) as dues, " +
+		"coalesce(sum(students.balance), 0) as balance, coalesce(sum(students.student_account_balance), 0) as student_account_balance").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	report.Students, report.HostelStudents = totals.Students, totals.HostelStudents
+	report.StudentsWithDues, report.Dues = totals.StudentsWithDues, totals.Dues
+	report.Balance, report.StudentAccountBalance = totals.Balance, totals.StudentAccountBalance
+
+	var groups []struct {
+		Name 	string
+		Count int64
+	}
+	err = students().Select("coalesce(nullif(students.status, ''), ?) as name, count(*) as count", StudentEnquiry).
+		Group("name").Scan(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		report.ByStatus[group.Name] = group.Count
+	}
+
+	groups = nil
+	err = students().Select("coalesce(standards.name, 'Not assigned') as name, count(*) as count").
+		Joins("left join batch_standard_students on batch_standard_students.student_id = students.id and batch_standard_students.deleted_at is null").
+		Joins("left join standards on standards.id = batch_standard_students.standard_id").
+		Group("name").Scan(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		report.ByStandard[group.Name] = group.Count
+	}
+	return report, nil
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+const (
+	StudentEnquiry = "Enquiry"
+	StudentAdmission = "Admission"
+	StudentConfirmed = "Confirmed"
+	StudentActive = "Active"
+	StudentPromoted = "Promoted"
+	StudentPassedOut = "Passed-out"
+	StudentDropped = "Dropped"
+	StudentTransferred = "Transferred"
+)
+
+// studentTransitions lists the statuses a student can move to from each
+// status. Passed-out, Dropped and Transferred are final.
+var studentTransitions = map[string][]string{
+	StudentEnquiry: {StudentAdmission, StudentDropped},
+	StudentAdmission: {StudentConfirmed, StudentDropped},
+	StudentConfirmed: {StudentActive, StudentDropped, StudentTransferred},
+	StudentActive: {StudentPromoted, StudentPassedOut, StudentDropped, StudentTransferred},
+	StudentPromoted: {StudentActive, StudentPassedOut, StudentDropped, StudentTransferred},
+}
+
+type StudentStatusChange struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" gorm:"index"`
+	FromStatus 			string `json:"from_status"`
+	ToStatus 				string `json:"to_status"`
+	Reason 					string `json:"reason" validate:"nonzero"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+  DeletedAt 			gorm.DeletedAt `gorm:"index"`
+}
+
+type StudentStatusResult struct {
+	StudentId 		uint `json:"student_id"`
+	StatusChange 	*StudentStatusChange `json:"status_change,omitempty"`
+	Error 				string `json:"error,omitempty"`
+}
+
+func migrateStudentStatusChange() {
+	fmt.Println("migrating student status change..")
+	err := db.Driver.AutoMigrate(&StudentStatusChange{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// CurrentStatus is the student's lifecycle status. Students saved before
+// statuses were tracked count as enquiries.
+func (s *Student) CurrentStatus() string {
+	if s.Status == "" {
+		return StudentEnquiry
+	}
+	return s.Status
+}
+
+func (s *Student) CanTransition(status string) bool {
+	for _, next := range studentTransitions[s.CurrentStatus()] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransition applies the guard conditions of the target status.
+func (s *Student) checkTransition(status string) error {
+	if !s.CanTransition(status) {
+		return swapErr.ErrInvalidStatusTransition
+	}
+
+	switch status {
+	case StudentConfirmed, StudentActive, StudentPromoted:
+		if len(s.GetBatchStandardStudents()) == 0 {
+			return swapErr.ErrNoClassAssigned
+		}
+	case StudentDropped, StudentPassedOut, StudentTransferred:
+		if err := s.SaveBalance(); err != nil {
+			return err
+		}
+		if s.Balance != 0.0 {
+			return swapErr.ErrBalanceNotCleared
+		}
+	}
+	return nil
+}
+
+// Transition moves the student to status and records who made the change
+// and why.
+func (s *Student) Transition(status string, reason string, userId int) (*StudentStatusChange, error) {
+	statusChange := &StudentStatusChange{StudentId: s.ID, FromStatus: s.CurrentStatus(), ToStatus: status, Reason: reason, UserID: userId}
+	if err := validator.Validate(statusChange); err != nil {
+		return nil, err
+	}
+	if err := s.checkTransition(status); err != nil {
+		return nil, err
+	}
+
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(statusChange).Error; err != nil {
+			return err
+		}
+		s.Status = status
+		return tx.Model(&Student{}).Where("id = ?", s.ID).Update("status", status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statusChange, nil
+}
+
+// TransitionStudents moves each of the students to status. Students that
+// cannot move are reported and do not stop the others.
+func TransitionStudents(studentIds []uint, status string, reason string, userId int) []StudentStatusResult {
+	results := []StudentStatusResult{}
+	for _, studentId := range studentIds {
+		result := StudentStatusResult{StudentId: studentId}
+		student := &Student{ID: studentId}
+		if err := student.Find(); err != nil {
+			result.Error = err.Error()
+		} else if statusChange, err := student.Transition(status, reason, userId); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.StatusChange = statusChange
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *Student) GetStatusChanges() ([]StudentStatusChange, error) {
+	var statusChanges []StudentStatusChange
+	err := db.Driver.Where("student_id = ?", s.ID).Order("id desc").Find(&statusChanges).Error
+	return statusChanges, err
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gopkg.in/validator.v2"
+)
+
+// Tag is a free-form label put on students, like "scholarship" or
+// "needs transport". Names are kept trimmed and in lower case.
+type Tag struct {
+	ID            	uint `json:"id"`
+	Name 						string `json:"name" validate:"nonzero,max=50" gorm:"uniqueIndex"`
+	StudentsCount 	int64 `json:"students_count" gorm:"->;-:migration"`
+	CreatedAt 			time.Time
+	UpdatedAt 			time.Time
+}
+
+// StudentTag puts a tag on a student, once.
+type StudentTag struct {
+	ID            	uint `json:"id"`
+	StudentId				uint `json:"student_id" gorm:"uniqueIndex:idx_student_tag"`
+	TagId 					uint `json:"tag_id" gorm:"uniqueIndex:idx_student_tag;index"`
+	UserID 					int `json:"user_id"`
+	CreatedAt 			time.Time
+}
+
+func migrateTag() {
+	fmt.Println("migrating tag..")
+	err := db.Driver.AutoMigrate(&Tag{}, &StudentTag{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+// TagName is the form a tag name is stored in.
+func TagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// TagNames cleans up names, dropping blank and repeated ones.
+func TagNames(names []string) []string {
+	cleaned := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = TagName(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			cleaned = append(cleaned, name)
+		}
+	}
+	return cleaned
+}
+
+func (t *Tag) Validate() error {
+	errs := validator.ErrorMap{}
+	if err := validator.Validate(t); err != nil {
+		errs = err.(validator.ErrorMap)
+	}
+	var taken int64
+	if err := db.Driver.Model(&Tag{}).Where("name = ? and id <> ?", t.Name, t.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		errs["Name"] = append(errs["Name"], validator.TextErr{Err: swapErr.ErrTagExists})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (t *Tag) Assign(tagData map[string]interface{}) {
+	if name, ok := tagData["name"].(string); ok {
+		t.Name = TagName(name)
+	}
+}
+
+// tagsQuery selects tags with the number of current students having them.
+func tagsQuery() *gorm.DB {
+	return db.Driver.Model(&Tag{}).Select("tags.*, (select count(*) from student_tags join students on students.id = student_tags.student_id " +
+		"and students.deleted_at is null where student_tags.tag_id = tags.id) as students_count")
+}
+
+// All lists the tags by name, those starting with prefix when one is given.
+func (t *Tag) All(prefix string) ([]Tag, error) {
+	tags := []Tag{}
+	query := tagsQuery()
+	if prefix = TagName(prefix); prefix != "" {
+		query = query.Where("tags.name like ?", prefix + "%")
+	}
+	err := query.Order("tags.name").Find(&tags).Error
+	return tags, err
+}
+
+func (t *Tag) Find() error {
+	err := tagsQuery().First(t, "tags.id = ?", t.ID).Error
+	return err
+}
+
+func (t *Tag) Update() error {
+	err := db.Driver.Model(t).Update("name", t.Name).Error
+	return err
+}
+
+// Delete removes the tag from every student.
+func (t *Tag) Delete() error {
+	return db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", t.ID).Delete(&StudentTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(t).Error
+	})
+}
+
+// GetTags lists the tags on the student by name.
+func (s *Student) GetTags() ([]Tag, error) {
+	tags := []Tag{}
+	err := db.Driver.Select("tags.*").Joins("join student_tags on student_tags.tag_id = tags.id").
+		Where("student_tags.student_id = ?", s.ID).Order("tags.name").Find(&tags).Error
+	return tags, err
+}
+
+// TagStudents puts the named tags on the students, creating the tags that do
+// not exist yet. Tags a student already has are left as they are; deleted
+// students are skipped. It returns the number of tags put on.
+func TagStudents(studentIds []uint, names []string, userId int) (int64, error) {
+	names = TagNames(names)
+	if len(names) == 0 {
+		return 0, swapErr.ErrNoTags
+	}
+	for _, name := range names {
+		tag := &Tag{Name: name}
+		if err := validator.Validate(tag); err != nil {
+			return 0, swapErr.ErrTagName
+		}
+	}
+
+	var tagged int64
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&Student{}).Where("id in (?)", studentIds).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for _, name := range names {
+			tag := &Tag{}
+			if err := tx.Where(Tag{Name: name}).FirstOrCreate(tag).Error; err != nil {
+				return err
+			}
+			links := []StudentTag{}
+			for _, id := range ids {
+				links = append(links, StudentTag{StudentId: id, TagId: tag.ID, UserID: userId})
+			}
+			if len(links) == 0 {
+				continue
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(links, 500)
+			if result.Error != nil {
+				return result.Error
+			}
+			tagged += result.RowsAffected
+		}
+		return nil
+	})
+	return tagged, err
+}
+
+// UntagStudents takes the named tags off the students and returns the number
+// taken off. The tags themselves are kept.
+func UntagStudents(studentIds []uint, names []string) (int64, error) {
+	names = TagNames(names)
+	if len(names) == 0 {
+		return 0, swapErr.ErrNoTags
+	}
+	result := db.Driver.Where("student_id in (?) and tag_id in (?)", studentIds,
+		db.Driver.Model(&Tag{}).Select("id").Where("name in (?)", names)).Delete(&StudentTag{})
+	return result.RowsAffected, result.Error
+}
+
+// mergeStudentTags moves the tags of a merged duplicate to the student,
+// dropping those the student already has.
+func mergeStudentTags(tx *gorm.DB, studentId uint, duplicateId uint) (int64, error) {
+	err := tx.Where("student_id = ? and tag_id in (?)", duplicateId,
+		tx.Model(&StudentTag{}).Select("tag_id").Where("student_id = ?", studentId)).Delete(&StudentTag{}).Error
+	if err != nil {
+		return 0, err
+	}
+	result := tx.Model(&StudentTag{}).Where("student_id = ?", duplicateId).Update("student_id", studentId)
+	return result.RowsAffected, result.Error
+}
+
+// taggedStudents keeps the students having every one of the named tags.
+func taggedStudents(query *gorm.DB, names []string) *gorm.DB {
+	for _, name := range TagNames(names) {
+		query = query.Where("students.id in (?)", db.Driver.Table("student_tags").Select("student_tags.student_id").
+			Joins("join tags on tags.id = student_tags.tag_id").Where("tags.name = ?", name))
+	}
+	return query
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"swapnil-ex/models/db"
+	"time"
+)
+
+const (
+	TimelineEnrolled = "enrolled"
+	TimelineUnenrolled = "unenrolled"
+	TimelineClassTransfer = "class_transfer"
+	TimelineHostelJoined = "hostel_joined"
+	TimelineHostelLeft = "hostel_left"
+	TimelineHostelTransfer = "hostel_transfer"
+	TimelineCharge = "charge"
+	TimelinePayment = "payment"
+	TimelineAdjustment = "adjustment"
+	TimelineWalletCredit = "wallet_credit"
+	TimelineWalletDebit = "wallet_debit"
+	TimelineStatusChange = "status_change"
+	TimelineDocument = "document"
+	TimelineNote = "note"
+)
+
+var TimelineTypes = []string{TimelineEnrolled, TimelineUnenrolled, TimelineClassTransfer, TimelineHostelJoined,
+	TimelineHostelLeft, TimelineHostelTransfer, TimelineCharge, TimelinePayment, TimelineAdjustment,
+	TimelineWalletCredit, TimelineWalletDebit, TimelineStatusChange, TimelineDocument, TimelineNote}
+
+// TimelineListFields can only be selected; the timeline is always newest first.
+var TimelineListFields = ListFields{"type": "", "occurred_at": "", "title": "", "amount": "", "source_id": "", "data": ""}
+
+// TimelineEvent is one entry of a student's timeline. Data is the record the
+// event was read from.
+type TimelineEvent struct {
+	Type 				string `json:"type"`
+	OccurredAt 	time.Time `json:"occurred_at"`
+	Title 			string `json:"title"`
+	Amount 			float64 `json:"amount,omitempty"`
+	SourceId 		uint `json:"source_id"`
+	Data 				interface{} `json:"data"`
+}
+
+// Timeline gathers the enrollments, hostel stays, transactions, wallet
+// entries, status changes, documents and notes of the student into one feed,
+// newest first, keeping only the given types when any are given. It returns
+// the page asked for by lq and the number of events in the feed.
+func (s *Student) Timeline(lq *ListQuery, types []string) ([]TimelineEvent, int64, error) {
+	wanted := map[string]bool{}
+	for _, eventType := range types {
+		wanted[eventType] = true
+	}
+	events := []TimelineEvent{}
+	add := func(eventType string, occurredAt time.Time, title string, amount float64, sourceId uint, data interface{}) {
+		if len(wanted) == 0 || wanted[eventType] {
+			events = append(events, TimelineEvent{Type: eventType, OccurredAt: occurredAt, Title: title, Amount: amount,
+				SourceId: sourceId, Data: data})
+		}
+	}
+
+	var transfers []BatchStandardTransfer
+	err := db.Driver.Preload("FromBatchStandard.Batch").Preload("FromBatchStandard.Standard").
+		Preload("ToBatchStandard.Batch").Preload("ToBatchStandard.Standard").Where("student_id = ?", s.ID).Find(&transfers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	transferredOut, transferredIn := map[uint]bool{}, map[uint]bool{}
+	for _, transfer := range transfers {
+		transferredOut[transfer.FromBatchStandardStudentId] = true
+		transferredIn[transfer.ToBatchStandardStudentId] = true
+		add(TimelineClassTransfer, timelineEffectiveAt(transfer.EffectiveOn, transfer.CreatedAt), fmt.Sprintf("Transferred from %s to %s",
+			batchStandardName(transfer.FromBatchStandard), batchStandardName(transfer.ToBatchStandard)), transfer.Amount, transfer.ID, transfer)
+	}
+
+	var batchStandardStudents []BatchStandardStudent
+	err = db.Driver.Unscoped().Preload("BatchStandard.Batch").Preload("BatchStandard.Standard").
+		Where("student_id = ?", s.ID).Find(&batchStandardStudents).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, bss := range batchStandardStudents {
+		className := batchStandardName(bss.BatchStandard)
+		if !transferredIn[bss.ID] {
+			add(TimelineEnrolled, bss.CreatedAt, "Enrolled in " + className, bss.Fee, bss.ID, bss)
+		}
+		if bss.DeletedAt.Valid && !transferredOut[bss.ID] {
+			add(TimelineUnenrolled, bss.DeletedAt.Time, "Left " + className, 0, bss.ID, bss)
+		}
+	}
+
+	var hostelStudents []HostelStudent
+	err = db.Driver.Unscoped().Preload("Hostel").Preload("HostelRoom").Where("student_id = ?", s.ID).Find(&hostelStudents).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, hs := range hostelStudents {
+		add(TimelineHostelJoined, hs.CreatedAt, fmt.Sprintf("Joined %s, room %s", hs.Hostel.Name, hs.HostelRoom.Name), 0, hs.ID, hs)
+		if hs.LeftOn != nil {
+			add(TimelineHostelLeft, *hs.LeftOn, "Left " + hs.Hostel.Name, 0, hs.ID, hs)
+		}
+	}
+
+	hostelTransfers, err := (&HostelTransfer{}).All(s.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, transfer := range hostelTransfers {
+		add(TimelineHostelTransfer, timelineEffectiveAt(transfer.EffectiveOn, transfer.CreatedAt), fmt.Sprintf("Moved from %s, room %s to %s, room %s",
+			transfer.FromHostel.Name, transfer.FromHostelRoom.Name, transfer.ToHostel.Name, transfer.ToHostelRoom.Name),
+			transfer.Amount, transfer.ID, transfer)
+	}
+
+	transactions, err := s.GetTransactions()
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, transaction := range transactions {
+		eventType := TimelineCharge
+		if transaction.TransactionType == "credit" {
+			eventType = TimelineAdjustment
+			if transaction.PaymentMode != "" && transaction.PaymentMode != "-" {
+				eventType = TimelinePayment
+			}
+		}
+		add(eventType, transaction.CreatedAt, transaction.Name, transaction.Amount, transaction.ID, transaction)
+	}
+
+	var studentAccounts []StudentAccount
+	if err := db.Driver.Where("student_id = ?", s.ID).Find(&studentAccounts).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, studentAccount := range studentAccounts {
+		eventType, title := TimelineWalletDebit, "Wallet debit"
+		if studentAccount.TransactionType == "credit" {
+			eventType, title = TimelineWalletCredit, "Wallet credit"
+		}
+		if studentAccount.Reason != "" {
+			title = title + ": " + studentAccount.Reason
+		}
+		add(eventType, studentAccount.CreatedAt, title, studentAccount.Amount, studentAccount.ID, studentAccount)
+	}
+
+	var statusChanges []StudentStatusChange
+	if err := db.Driver.Where("student_id = ?", s.ID).Find(&statusChanges).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, statusChange := range statusChanges {
+		add(TimelineStatusChange, statusChange.CreatedAt, fmt.Sprintf("Status changed from %s to %s",
+			statusChange.FromStatus, statusChange.ToStatus), 0, statusChange.ID, statusChange)
+	}
+
+	documents, err := (&StudentDocument{}).All(s.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, document := range documents {
+		add(TimelineDocument, document.CreatedAt, fmt.Sprintf("Uploaded %s: %s", document.Category, document.FileName), 0, document.ID, document)
+	}
+
+	notes, err := (&StudentNote{}).All(s.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, note := range notes {
+		add(TimelineNote, note.CreatedAt, note.Note, 0, note.ID, note)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].OccurredAt.Equal(events[j].OccurredAt) {
+			return events[i].OccurredAt.After(events[j].OccurredAt)
+		}
+		return events[i].SourceId > events[j].SourceId
+	})
+	total := int64(len(events))
+	if lq.PageSize > 0 {
+		start := (lq.Page - 1) * lq.PageSize
+		if start > len(events) {
+			start = len(events)
+		}
+		end := start + lq.PageSize
+		if end > len(events) {
+			end = len(events)
+		}
+		events = events[start:end]
+	}
+	return events, total, nil
+}
+
+func batchStandardName(bs BatchStandard) string {
+	if bs.Batch.Name == "" {
+		return bs.Standard.Name
+	}
+	return bs.Standard.Name + " (" + bs.Batch.Name + ")"
+}
+
+// timelineEffectiveAt places a change effective on the day it was recorded at
+// the time it was recorded, so it follows the events of that day before it.
+func timelineEffectiveAt(effectiveOn time.Time, createdAt time.Time) time.Time {
+	if startOfDay(createdAt).Equal(startOfDay(effectiveOn)) {
+		return createdAt
+	}
+	return effectiveOn
+}
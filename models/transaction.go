@@ -27,6 +27,8 @@ type Transaction struct {
 	RecieptUrl  						string `json:"receipt_url"`
 	UserID									uint `json:"user_id"`
 	Reason 									string `json:"reason"`
+	FiscalYearId						uint `json:"fiscal_year_id"`
+	IsOpeningBalance				bool `json:"is_opening_balance" gorm:"default:false"`
 	AmountToWord						string `gorm:"-:all"`
 	Student 								Student
 	CreatedAt 							time.Time
@@ -98,29 +100,46 @@ func (t *Transaction) Assign(transactionData map[string]interface{}) {
 }
 
 
-func (t *Transaction) AllStudents(page int, ids []uint) ([]Transaction, error) {
+var TransactionListFields = ListFields{"id": "id", "receipt_id": "receipt_id", "name": "name", "student_id": "student_id",
+	"hostel_student_id": "hostel_student_id", "transaction_category_id": "transaction_category_id",
+	"batch_standard_student_id": "batch_standard_student_id", "paid_by": "paid_by", "payment_mode": "payment_mode",
+	"is_cleared": "is_cleared", "is_checked": "is_checked", "transaction_type": "transaction_type", "amount": "amount",
+	"receipt_url": "", "user_id": "user_id", "reason": "reason", "fiscal_year_id": "fiscal_year_id",
+	"is_opening_balance": "is_opening_balance", "student": "", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (t *Transaction) AllStudents(lq *ListQuery, ids []uint, fiscalYear *FiscalYear) ([]Transaction, error) {
 	var transactions []Transaction
 	query := db.Driver.Preload("Student")
 	if len(ids) > 0 {	
 		query = query.Where("student_id in (?)", ids)
 	}
-	err := query.Limit(10).Offset((page - 1) * 10).Order("id desc").Find(&transactions).Error
+	if fiscalYear != nil {
+		query = query.Scopes(fiscalYear.Scope)
+	}
+	err := query.Scopes(lq.Scope).Find(&transactions).Error
 	return transactions, err
 }
 
-func (t *Transaction) Count(ids []uint) (int64, error) {
+func (t *Transaction) Count(lq *ListQuery, ids []uint, fiscalYear *FiscalYear) (int64, error) {
 	var count int64
-	query := db.Driver.Model(&Transaction{})
+	query := db.Driver.Model(&Transaction{}).Scopes(lq.Filter)
 	if len(ids) > 0 {	
 		query = query.Where("student_id in (?)", ids)
 	}
+	if fiscalYear != nil {
+		query = query.Scopes(fiscalYear.Scope)
+	}
 	err := query.Count(&count).Error
 	return count, err
 }
 
-func (t *Transaction) All(studentId int) ([]Transaction, error) {
+func (t *Transaction) All(lq *ListQuery, studentId int, fiscalYear *FiscalYear) ([]Transaction, error) {
 	var transactions []Transaction
-	err := db.Driver.Where("student_id = ?", studentId).Find(&transactions).Error
+	query := db.Driver.Where("student_id = ?", studentId)
+	if fiscalYear != nil {
+		query = query.Scopes(fiscalYear.Scope)
+	}
+	err := query.Scopes(lq.Scope).Find(&transactions).Error
 	return transactions, err
 }
 
@@ -130,20 +149,39 @@ func (t *Transaction) Find() error {
 }
 
 func (t *Transaction) Create() error {
-	receiptId, err :=  t.getReiceptId()
+	return t.create(db.Driver)
+}
+
+// create posts the transaction through tx, so it can be part of a larger
+// database transaction. The student balance is left to the caller.
+func (t *Transaction) create(tx *gorm.DB) error {
+	createdAt := t.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	if inClosedFiscalYear(tx, createdAt) {
+		return swapErr.ErrFiscalYearClosed
+	}
+	receiptId, err :=  t.getReiceptId(tx)
 	if err == nil {
 		t.ReceiptId = receiptId
 	}
-	err = db.Driver.Omit("Student").Create(t).Error
+	err = tx.Omit("Student").Create(t).Error
 	return err
 }
 
 func (t *Transaction) Update() error {
+	if InClosedFiscalYear(t.CreatedAt) {
+		return swapErr.ErrFiscalYearClosed
+	}
 	err := db.Driver.Save(t).Error
 	return err
 }
 
 func (t *Transaction) Delete() error {
+	if InClosedFiscalYear(t.CreatedAt) {
+		return swapErr.ErrFiscalYearClosed
+	}
 	err := db.Driver.Delete(t).Error
 	return err
 }
@@ -158,12 +196,12 @@ func (t *Transaction) CheckedTransaction() error {
 		}
 }
 
-func (t *Transaction) getReiceptId() (string , error) {
+func (t *Transaction) getReiceptId(tx *gorm.DB) (string , error) {
 	var count int64
 	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
 	//err := 
 	var transactions []Transaction
-	err := db.Driver.Where("created_at > ?", today).Find(&transactions).Count(&count).Error
+	err := tx.Where("created_at > ?", today).Find(&transactions).Count(&count).Error
 	if err != nil  {
 		return "", err
 	} else {
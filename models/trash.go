@@ -0,0 +1,598 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"swapnil-ex/constants"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+)
+
+const (
+	TrashRestore = "restore"
+	TrashPurge = "purge"
+)
+
+// trashLink is a table whose column holds the id of a record of another.
+type trashLink struct {
+	table 	string
+	column 	string
+}
+
+// trashEntity describes how a soft deleted model is restored and purged.
+// Parents have to be live before a record is restored. Children deleted at
+// the same moment as the record are restored with it, and deleted children
+// are purged with it. Blockers are rows that still refer to the record and
+// stop a purge, history rows about the record are removed with it. Ended
+// rows were deleted when they became past records, such as a finished hostel
+// stay, and are not trash: they are neither listed, restored nor purged
+// except with their student.
+type trashEntity struct {
+	model 		interface{}
+	parents 	map[string]string
+	children 	[]trashLink
+	blockers 	[]trashLink
+	history 	[]trashLink
+	ended 		string
+	// check runs in the restore transaction before the record comes back
+	check 		func(tx *gorm.DB, id uint) error
+	// locked refuses restoring or purging the record
+	locked 		func(tx *gorm.DB, id uint) error
+	// restored and purged run once the transaction is committed
+	restored 	func(id uint)
+	purged 		func(record interface{})
+}
+
+// trashEntities are the models with a trash, by the name used in the URL,
+// which is also their table.
+var trashEntities = map[string]*trashEntity{
+	"students": {model: &Student{},
+		children: []trashLink{{"transactions", "student_id"}, {"student_accounts", "student_id"}, {"student_documents", "student_id"},
+			{"student_notes", "student_id"}, {"batch_standard_students", "student_id"}, {"hostel_students", "student_id"}},
+		blockers: []trashLink{{"deposits", "student_id"}, {"write_offs", "student_id"}, {"pos_sales", "student_id"},
+			{"certificates", "student_id"}},
+		history: []trashLink{{"student_status_changes", "student_id"}, {"student_guardians", "student_id"},
+			{"batch_standard_transfers", "student_id"}, {"hostel_transfers", "student_id"}, {"student_tags", "student_id"}},
+		check: checkStudentRestore, restored: restoredStudent},
+	"batches": {model: &Batch{},
+		children: []trashLink{{"batch_standards", "batch_id"}},
+		blockers: []trashLink{{"write_offs", "batch_id"}}},
+	"standards": {model: &Standard{},
+		children: []trashLink{{"batch_standards", "standard_id"}}},
+	"batch_standards": {model: &BatchStandard{},
+		parents: map[string]string{"batch_id": "batches", "standard_id": "standards"},
+		children: []trashLink{{"batch_standard_students", "batch_standard_id"}},
+		history: []trashLink{{"fee_revisions", "batch_standard_id"}, {"transaction_categories", "batch_standard_id"}},
+		restored: restoredBatchStandard},
+	"batch_standard_students": {model: &BatchStandardStudent{},
+		parents: map[string]string{"student_id": "students", "batch_standard_id": "batch_standards"},
+		blockers: []trashLink{{"transactions", "batch_standard_student_id"}, {"deposits", "batch_standard_student_id"},
+			{"write_offs", "batch_standard_student_id"}},
+		ended: "exists (select 1 from batch_standard_transfers " +
+			"where batch_standard_transfers.from_batch_standard_student_id = batch_standard_students.id) or " +
+			"exists (select 1 from promotions, json_each(promotions.report, '$.students') as promoted " +
+			"where promotions.deleted_at is null and json_extract(promoted.value, '$.result') = 'promoted' and " +
+			"json_extract(promoted.value, '$.student_id') = batch_standard_students.student_id and " +
+			"json_extract(promoted.value, '$.from_batch_standard_id') = batch_standard_students.batch_standard_id)",
+		check: checkBatchStandardStudentRestore, restored: restoredBatchStandardStudent},
+	"hostels": {model: &Hostel{},
+		children: []trashLink{{"hostel_rooms", "hostel_id"}}},
+	"hostel_rooms": {model: &HostelRoom{},
+		parents: map[string]string{"hostel_id": "hostels"},
+		children: []trashLink{{"hostel_students", "hostel_room_id"}}},
+	"hostel_students": {model: &HostelStudent{},
+		parents: map[string]string{"student_id": "students", "hostel_id": "hostels", "hostel_room_id": "hostel_rooms"},
+		blockers: []trashLink{{"transactions", "hostel_student_id"}, {"deposits", "hostel_student_id"}},
+		history: []trashLink{{"hostel_charges", "hostel_student_id"}, {"hostel_transfers", "hostel_student_id"}},
+		ended: "hostel_students.left_on is not null",
+		check: checkHostelStudentRestore, restored: restoredHostelStudent},
+	"transactions": {model: &Transaction{},
+		parents: map[string]string{"student_id": "students"},
+		blockers: []trashLink{{"write_offs", "transaction_id"}},
+		history: []trashLink{{"cheques", "transaction_id"}, {"hostel_charges", "transaction_id"}},
+		locked: lockedTransaction, restored: restoredTransaction},
+	"student_accounts": {model: &StudentAccount{},
+		parents: map[string]string{"student_id": "students"},
+		blockers: []trashLink{{"pos_sales", "student_account_id"}},
+		restored: restoredStudentAccount},
+	"student_documents": {model: &StudentDocument{},
+		parents: map[string]string{"student_id": "students"},
+		purged: purgedStudentDocument},
+	"student_notes": {model: &StudentNote{},
+		parents: map[string]string{"student_id": "students"}},
+	"pos_items": {model: &PosItem{},
+		blockers: []trashLink{{"pos_sale_lines", "pos_item_id"}}},
+	"users": {model: &User{}},
+}
+
+var TrashListFields = ListFields{"id": "id", "deleted_at": "deleted_at", "created_at": "created_at"}
+
+var TrashLogListFields = ListFields{"id": "id", "entity": "entity", "record_id": "record_id", "action": "action",
+	"record": "", "reason": "", "user_id": "user_id", "created_at": "created_at"}
+
+// TrashLog records every restore and purge, with the record as it was.
+type TrashLog struct {
+	ID            	uint `json:"id"`
+	Entity 					string `json:"entity" gorm:"index"`
+	RecordId 				uint `json:"record_id"`
+	Action 					string `json:"action"`
+	Record 					string `json:"record"`
+	Reason 					string `json:"reason"`
+	UserID					int `json:"user_id"`
+	CreatedAt 			time.Time
+}
+
+type TrashSummary struct {
+	Entity 	string `json:"entity"`
+	Count 	int64 `json:"count"`
+}
+
+type TrashItem struct {
+	Entity 		string `json:"entity"`
+	RecordId 	uint `json:"record_id"`
+}
+
+// TrashPurgeReport lists what a purge removed and the records it left,
+// with why.
+type TrashPurgeReport struct {
+	Purged 		[]TrashItem `json:"purged"`
+	Skipped 	map[uint]string `json:"skipped"`
+}
+
+func migrateTrashLog() {
+	fmt.Println("migrating trash log..")
+	err := db.Driver.AutoMigrate(&TrashLog{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func (tl *TrashLog) All(lq *ListQuery) ([]TrashLog, error) {
+	var logs []TrashLog
+	err := db.Driver.Scopes(lq.Scope).Find(&logs).Error
+	return logs, err
+}
+
+func (tl *TrashLog) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&TrashLog{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
+func IsTrashEntity(entity string) bool {
+	_, ok := trashEntities[entity]
+	return ok
+}
+
+// TrashSummaries counts the deleted records of every entity.
+func TrashSummaries() ([]TrashSummary, error) {
+	summaries := []TrashSummary{}
+	for entity, te := range trashEntities {
+		summary := TrashSummary{Entity: entity}
+		if err := db.Driver.Table(entity).Scopes(te.trashed).Count(&summary.Count).Error; err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Entity < summaries[j].Entity
+	})
+	return summaries, nil
+}
+
+// Trash returns a page of the deleted records of entity and their count.
+func Trash(entity string, lq *ListQuery) (interface{}, int64, error) {
+	te := trashEntities[entity]
+	records := reflect.New(reflect.SliceOf(reflect.TypeOf(te.model).Elem()))
+	var count int64
+	if err := db.Driver.Unscoped().Model(te.model).Scopes(te.trashed, lq.Filter).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Driver.Unscoped().Scopes(te.trashed, lq.Scope).Find(records.Interface()).Error
+	return records.Elem().Interface(), count, err
+}
+
+// RestoreTrash brings a deleted record back along with the children deleted
+// with it. It is refused while a parent is deleted or the record would clash
+// with a live one; a child that cannot come back stays in the trash.
+func RestoreTrash(entity string, id uint, reason string, userId int) ([]TrashItem, error) {
+	restored := []TrashItem{}
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := trashEntities[entity].listed(tx, id); err != nil {
+			return err
+		}
+		var err error
+		restored, err = restoreRecord(tx, entity, id, reason, userId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range restored {
+		if hook := trashEntities[item.Entity].restored; hook != nil {
+			hook(item.RecordId)
+		}
+	}
+	return restored, nil
+}
+
+func restoreRecord(tx *gorm.DB, entity string, id uint, reason string, userId int) ([]TrashItem, error) {
+	te := trashEntities[entity]
+	record, err := findTrash(tx, te, id)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(te.parents))
+	for column := range te.parents {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		var parentIds []uint
+		if err := tx.Unscoped().Table(entity).Where("id = ?", id).Pluck(column, &parentIds).Error; err != nil {
+			return nil, err
+		}
+		if len(parentIds) == 0 || parentIds[0] == 0 {
+			continue
+		}
+		var live int64
+		if err := tx.Table(te.parents[column]).Where("id = ? and deleted_at is null", parentIds[0]).Count(&live).Error; err != nil {
+			return nil, err
+		}
+		if live == 0 {
+			return nil, fmt.Errorf("%w: restore %s %d first", swapErr.ErrTrashDependency, te.parents[column], parentIds[0])
+		}
+	}
+	if te.locked != nil {
+		if err := te.locked(tx, id); err != nil {
+			return nil, err
+		}
+	}
+	if te.check != nil {
+		if err := te.check(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var children [][]uint
+	for _, child := range te.children {
+		var childIds []uint
+		err := tx.Unscoped().Table(child.table).Where(child.column + " = ?", id).
+			Where("deleted_at = (?)", tx.Unscoped().Table(entity).Select("deleted_at").Where("id = ?", id)).
+			Pluck("id", &childIds).Error
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, childIds)
+	}
+
+	if err := tx.Unscoped().Table(entity).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	if err := logTrash(tx, entity, id, TrashRestore, record, reason, userId); err != nil {
+		return nil, err
+	}
+	restored := []TrashItem{{Entity: entity, RecordId: id}}
+
+	for i, child := range te.children {
+		for _, childId := range children[i] {
+			items, err := restoreRecord(tx, child.table, childId, reason, userId)
+			if err == swapErr.ErrAlreadyHasClass || err == swapErr.ErrAlreadyInRoom || err == swapErr.ErrRoomFull {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			restored = append(restored, items...)
+		}
+	}
+	return restored, nil
+}
+
+// PurgeTrash permanently removes a record deleted more than
+// TRASH_RETENTION_DAYS ago, with its deleted children and its history.
+func PurgeTrash(entity string, id uint, reason string, userId int) ([]TrashItem, error) {
+	purged := []TrashItem{}
+	records := []interface{}{}
+	cutoff := time.Now().AddDate(0, 0, -constants.TRASH_RETENTION_DAYS)
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		if err := trashEntities[entity].listed(tx, id); err != nil {
+			return err
+		}
+		var err error
+		purged, records, err = purgeRecord(tx, entity, id, cutoff, reason, userId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range purged {
+		if hook := trashEntities[item.Entity].purged; hook != nil {
+			hook(records[i])
+		}
+	}
+	return purged, nil
+}
+
+// PurgeExpiredTrash purges every record of entity past the retention period,
+// leaving those that something still refers to.
+func PurgeExpiredTrash(entity string, reason string, userId int) (*TrashPurgeReport, error) {
+	report := &TrashPurgeReport{Purged: []TrashItem{}, Skipped: map[uint]string{}}
+	cutoff := time.Now().AddDate(0, 0, -constants.TRASH_RETENTION_DAYS)
+	var ids []uint
+	if err := db.Driver.Unscoped().Table(entity).Scopes(trashEntities[entity].trashed).Where("deleted_at < ?", cutoff).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		purged, err := PurgeTrash(entity, id, reason, userId)
+		if err == swapErr.ErrNotInTrash {
+			// purged already with an earlier record
+			continue
+		}
+		if err != nil {
+			report.Skipped[id] = err.Error()
+			continue
+		}
+		report.Purged = append(report.Purged, purged...)
+	}
+	return report, nil
+}
+
+func purgeRecord(tx *gorm.DB, entity string, id uint, cutoff time.Time, reason string, userId int) ([]TrashItem, []interface{}, error) {
+	te := trashEntities[entity]
+	record, err := findTrash(tx, te, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	var expired int64
+	if err := tx.Unscoped().Table(entity).Where("id = ? and deleted_at < ?", id, cutoff).Count(&expired).Error; err != nil {
+		return nil, nil, err
+	}
+	if expired == 0 {
+		return nil, nil, swapErr.ErrTrashRetention
+	}
+	if te.locked != nil {
+		if err := te.locked(tx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	purged, records := []TrashItem{}, []interface{}{}
+	for _, child := range te.children {
+		var live int64
+		if err := tx.Table(child.table).Where(child.column + " = ? and deleted_at is null", id).Count(&live).Error; err != nil {
+			return nil, nil, err
+		}
+		if live > 0 {
+			return nil, nil, fmt.Errorf("%w: %d %s", swapErr.ErrTrashDependency, live, child.table)
+		}
+		var childIds []uint
+		if err := tx.Unscoped().Table(child.table).Where(child.column + " = ?", id).Pluck("id", &childIds).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, childId := range childIds {
+			items, childRecords, err := purgeRecord(tx, child.table, childId, cutoff, reason, userId)
+			if err != nil {
+				return nil, nil, err
+			}
+			purged, records = append(purged, items...), append(records, childRecords...)
+		}
+	}
+	for _, blocker := range te.blockers {
+		var count int64
+		if err := tx.Unscoped().Table(blocker.table).Where(blocker.column + " = ?", id).Count(&count).Error; err != nil {
+			return nil, nil, err
+		}
+		if count > 0 {
+			return nil, nil, fmt.Errorf("%w: %d %s", swapErr.ErrTrashDependency, count, blocker.table)
+		}
+	}
+	for _, history := range te.history {
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", history.table, history.column), id).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	if entity == "students" {
+		if err := unindexStudent(tx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Unscoped().Delete(te.model, id).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := logTrash(tx, entity, id, TrashPurge, record, reason, userId); err != nil {
+		return nil, nil, err
+	}
+	return append(purged, TrashItem{Entity: entity, RecordId: id}), append(records, record), nil
+}
+
+// trashed keeps the deleted rows of the entity that are trash, leaving out
+// the ended ones.
+func (te *trashEntity) trashed(query *gorm.DB) *gorm.DB {
+	query = query.Where("deleted_at is not null")
+	if te.ended != "" {
+		query = query.Where("not (" + te.ended + ")")
+	}
+	return query
+}
+
+// listed refuses a record that is not in the trash listing.
+func (te *trashEntity) listed(tx *gorm.DB, id uint) error {
+	var count int64
+	if err := tx.Unscoped().Model(te.model).Scopes(te.trashed).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return swapErr.ErrNotInTrash
+	}
+	return nil
+}
+
+func findTrash(tx *gorm.DB, te *trashEntity, id uint) (interface{}, error) {
+	record := reflect.New(reflect.TypeOf(te.model).Elem()).Interface()
+	err := tx.Unscoped().Where("id = ? and deleted_at is not null", id).Take(record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, swapErr.ErrNotInTrash
+	}
+	return record, err
+}
+
+func logTrash(tx *gorm.DB, entity string, id uint, action string, record interface{}, reason string, userId int) error {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&TrashLog{Entity: entity, RecordId: id, Action: action, Record: string(recordJSON),
+		Reason: reason, UserID: userId}).Error
+}
+
+func checkStudentRestore(tx *gorm.DB, id uint) error {
+	var merged int64
+	if err := tx.Model(&StudentMerge{}).Where("duplicate_id = ?", id).Count(&merged).Error; err != nil {
+		return err
+	}
+	if merged > 0 {
+		return swapErr.ErrStudentMerged
+	}
+	return nil
+}
+
+// checkBatchStandardStudentRestore keeps a student in one class and gives
+// up the roll number when another student has taken it meanwhile.
+func checkBatchStandardStudentRestore(tx *gorm.DB, id uint) error {
+	var bss BatchStandardStudent
+	if err := tx.Unscoped().First(&bss, id).Error; err != nil {
+		return err
+	}
+	var enrolled int64
+	if err := tx.Model(&BatchStandardStudent{}).Where("student_id = ?", bss.StudentId).Count(&enrolled).Error; err != nil {
+		return err
+	}
+	if enrolled > 0 {
+		return swapErr.ErrAlreadyHasClass
+	}
+	if bss.RollNumber == "" {
+		return nil
+	}
+	var taken int64
+	err := tx.Model(&BatchStandardStudent{}).Where("batch_standard_id = ? and roll_number = ?", bss.BatchStandardId, bss.RollNumber).
+		Count(&taken).Error
+	if err != nil || taken == 0 {
+		return err
+	}
+	return tx.Unscoped().Model(&BatchStandardStudent{}).Where("id = ?", id).Updates(map[string]interface{}{"roll_number": "", "roll_sequence": 0}).Error
+}
+
+func checkHostelStudentRestore(tx *gorm.DB, id uint) error {
+	var hs HostelStudent
+	if err := tx.Unscoped().First(&hs, id).Error; err != nil {
+		return err
+	}
+	var staying int64
+	if err := tx.Model(&HostelStudent{}).Where("student_id = ?", hs.StudentId).Count(&staying).Error; err != nil {
+		return err
+	}
+	if staying > 0 {
+		return swapErr.ErrAlreadyInRoom
+	}
+	var room HostelRoom
+	if err := tx.First(&room, hs.HostelRoomId).Error; err != nil {
+		return err
+	}
+	var occupied int64
+	if err := tx.Model(&HostelStudent{}).Where("hostel_room_id = ?", room.ID).Count(&occupied).Error; err != nil {
+		return err
+	}
+	if room.NoOfStudents > 0 && occupied >= int64(room.NoOfStudents) {
+		return swapErr.ErrRoomFull
+	}
+	return nil
+}
+
+func restoredStudent(id uint) {
+	student := &Student{ID: id}
+	if err := student.Find(); err != nil {
+		return
+	}
+	indexStudent(db.Driver, id)
+	student.SaveBalance()
+	student.SaveStudentAccountBalance()
+}
+
+func restoredBatchStandard(id uint) {
+	bs := &BatchStandard{ID: id}
+	if err := bs.Find(); err == nil {
+		bs.updateCount()
+	}
+}
+
+func restoredBatchStandardStudent(id uint) {
+	bss := &BatchStandardStudent{}
+	if err := db.Driver.First(bss, id).Error; err != nil {
+		return
+	}
+	bss.updateCount(db.Driver)
+	if bss.RollNumber != "" {
+		db.Driver.Model(&Student{}).Where("id = ?", bss.StudentId).Update("roll_number", bss.RollNumber)
+		indexStudent(db.Driver, bss.StudentId)
+	}
+}
+
+func restoredHostelStudent(id uint) {
+	hs := &HostelStudent{}
+	if err := db.Driver.First(hs, id).Error; err != nil {
+		return
+	}
+	hs.updateCount(db.Driver)
+	db.Driver.Model(&Student{}).Where("id = ?", hs.StudentId).Update("has_hostel", hs.LeftOn == nil)
+}
+
+// lockedTransaction keeps the transactions of a closed fiscal year, and the
+// cheques and charges tied to them, as they are.
+func lockedTransaction(tx *gorm.DB, id uint) error {
+	var transaction Transaction
+	if err := tx.Unscoped().First(&transaction, id).Error; err != nil {
+		return err
+	}
+	if inClosedFiscalYear(tx, transaction.CreatedAt) {
+		return swapErr.ErrFiscalYearClosed
+	}
+	return nil
+}
+
+func restoredTransaction(id uint) {
+	transaction := &Transaction{}
+	if err := db.Driver.First(transaction, id).Error; err != nil {
+		return
+	}
+	student := &Student{ID: transaction.StudentId}
+	if err := student.Find(); err == nil {
+		student.SaveBalance()
+	}
+}
+
+func restoredStudentAccount(id uint) {
+	studentAccount := &StudentAccount{}
+	if err := db.Driver.First(studentAccount, id).Error; err != nil {
+		return
+	}
+	student := &Student{ID: studentAccount.StudentId}
+	if err := student.Find(); err == nil {
+		student.SaveStudentAccountBalance()
+	}
+}
+
+// purgedStudentDocument removes the stored file, which is kept while the
+// document is in the trash.
+func purgedStudentDocument(record interface{}) {
+	document := record.(*StudentDocument)
+	if err := DocumentStorage().Delete(document.StorageKey); err != nil {
+		fmt.Println("storage.Delete(purgedStudentDocument)", err)
+	}
+}
@@ -56,12 +56,20 @@ func (u *User) Save() error {
 	return err
 }
 
-func (u *User) All() ([]User, error) {
+var UserListFields = ListFields{"id": "id", "username": "username", "role": "role", "created_at": "created_at", "updated_at": "updated_at"}
+
+func (u *User) All(lq *ListQuery) ([]User, error) {
 	var users []User
-	err := db.Driver.Find(&users).Error
+	err := db.Driver.Scopes(lq.Scope).Find(&users).Error
 	return users, err
 }
 
+func (u *User) Count(lq *ListQuery) (int64, error) {
+	var count int64
+	err := db.Driver.Model(&User{}).Scopes(lq.Filter).Count(&count).Error
+	return count, err
+}
+
 func (u *User) Validate() error {
 	if u.ConfirmPassword != u.Password {
 		return swapErr.ErrPasswordMisMatch
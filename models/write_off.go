@@ -0,0 +1,229 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"swapnil-ex/models/db"
+	"swapnil-ex/swapErr"
+	"time"
+	"gorm.io/gorm"
+	"gopkg.in/validator.v2"
+)
+
+// WriteOff clears dues that will not be collected. The amount is credited to
+// the student's ledger under the Bad Debt category, so the dues stay on record.
+type WriteOff struct {
+	ID            					uint `json:"id"`
+	StudentId								uint `json:"student_id" validate:"nonzero"`
+	BatchId 								uint `json:"batch_id" gorm:"index"`
+	BatchStandardStudentId	uint `json:"batch_standard_student_id"`
+	Amount       						float64 `json:"amount" validate:"nonzero"`
+	Reason 									string `json:"reason" validate:"nonzero"`
+	Status 									string `json:"status" gorm:"default:'Written Off'"`
+	TransactionId 					uint `json:"transaction_id"`
+	UserID									int `json:"user_id"`
+	ReversalReason 					string `json:"reversal_reason"`
+	ReversalTransactionId 	uint `json:"reversal_transaction_id"`
+	ReversedBy 							int `json:"reversed_by"`
+	ReversedAt 							*time.Time `json:"reversed_at"`
+	student 								*Student
+	CreatedAt 							time.Time
+	UpdatedAt 							time.Time
+  DeletedAt 							gorm.DeletedAt `gorm:"index"`
+}
+
+type WriteOffSummary struct {
+	BatchId 		uint `json:"batch_id"`
+	BatchName 	string `json:"batch_name"`
+	FiscalYear 	string `json:"fiscal_year"`
+	WriteOffs 	int64 `json:"write_offs"`
+	Amount 			float64 `json:"amount"`
+	Reversed 		float64 `json:"reversed"`
+	Outstanding float64 `json:"outstanding"`
+}
+
+func migrateWriteOff() {
+	fmt.Println("migrating write off..")
+	err := db.Driver.AutoMigrate(&WriteOff{})
+	if err != nil {
+		panic("failed to migrate database")
+	}
+}
+
+func NewWriteOff(writeOffData map[string]interface{}, student *Student) *WriteOff {
+	writeOff := &WriteOff{StudentId: student.ID, student: student}
+	writeOff.Assign(writeOffData)
+	return writeOff
+}
+
+func (w *WriteOff) Validate() error {
+	if errs := validator.Validate(w); errs != nil {
+		return errs
+	}
+
+	if w.BatchStandardStudentId > 0 {
+		batchStandardStudent := &BatchStandardStudent{ID: w.BatchStandardStudentId}
+		if err := batchStandardStudent.Find(); err != nil || batchStandardStudent.StudentId != w.StudentId {
+			return validator.ErrorMap{"BatchStandardStudentId": validator.ErrorArray{swapErr.ErrBadData}}
+		}
+		w.BatchId = batchStandardStudent.BatchId
+	} else if batchStandardStudents := w.student.GetBatchStandardStudents(); len(batchStandardStudents) > 0 {
+		w.BatchStandardStudentId = batchStandardStudents[0].ID
+		w.BatchId = batchStandardStudents[0].BatchId
+	}
+
+	if w.Amount < 0.0 || w.Amount > math.Round(-w.student.Balance * 100) / 100 {
+		return validator.ErrorMap{"Amount": validator.ErrorArray{swapErr.ErrWriteOffExceedsDues}}
+	}
+	return nil
+}
+
+func (w *WriteOff) Assign(writeOffData map[string]interface{}) {
+	if batchStandardStudentId, ok := writeOffData["batch_standard_student_id"]; ok {
+		w.BatchStandardStudentId = uint(batchStandardStudentId.(float64))
+	}
+
+	if amount, ok := writeOffData["amount"]; ok {
+		w.Amount = amount.(float64)
+	}
+
+	if reason, ok := writeOffData["reason"]; ok {
+		w.Reason = reason.(string)
+	}
+}
+
+func (w *WriteOff) All(studentId uint) ([]WriteOff, error) {
+	var writeOffs []WriteOff
+	err := db.Driver.Where("student_id = ?", studentId).Order("id desc").Find(&writeOffs).Error
+	return writeOffs, err
+}
+
+func (w *WriteOff) Find() error {
+	err := db.Driver.First(w, "ID = ?", w.ID).Error
+	return err
+}
+
+func (w *WriteOff) Update() error {
+	err := db.Driver.Save(w).Error
+	return err
+}
+
+// Create posts the write-off as a Bad Debt credit on the student's ledger,
+// together with the write-off itself.
+func (w *WriteOff) Create() error {
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		transaction, err := w.post(tx, "Bad Debt Write-off", "credit", w.Reason)
+		if err != nil {
+			return err
+		}
+		w.TransactionId = transaction.ID
+		return tx.Create(w).Error
+	})
+	if err != nil {
+		w.TransactionId = 0
+		return err
+	}
+	return saveStudentBalance(w.StudentId)
+}
+
+// Reverse restores the written off dues with a Bad Debt debit, when the money
+// turns out to be recoverable. The recovered payment is then taken as usual.
+func (w *WriteOff) Reverse(reason string, userId int) error {
+	if w.Status != "Written Off" {
+		return swapErr.ErrWriteOffReversed
+	}
+	if reason == "" {
+		return validator.ErrorMap{"ReversalReason": validator.ErrorArray{validator.ErrZeroValue}}
+	}
+
+	writeOff := *w
+	err := db.Driver.Transaction(func(tx *gorm.DB) error {
+		transaction, err := w.post(tx, "Bad Debt Write-off Reversal", "debit", reason)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		w.Status = "Reversed"
+		w.ReversalReason = reason
+		w.ReversalTransactionId = transaction.ID
+		w.ReversedBy = userId
+		w.ReversedAt = &now
+		return tx.Save(w).Error
+	})
+	if err != nil {
+		*w = writeOff
+		return err
+	}
+	return saveStudentBalance(w.StudentId)
+}
+
+func (w *WriteOff) post(tx *gorm.DB, name string, transactionType string, reason string) (*Transaction, error) {
+	transactionCategory, err := badDebtCategory(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionData := map[string]interface{}{"name": name, "student_id": float64(w.StudentId),
+		"batch_standard_student_id": float64(w.BatchStandardStudentId), "transaction_category_id": float64(transactionCategory.ID),
+		"is_cleared": true, "transaction_type": transactionType, "payment_mode": "Write-off", "paid_by": "-",
+		"reason": reason, "amount": w.Amount}
+	transaction := NewTransaction(transactionData, Student{ID: w.StudentId})
+	if err := transaction.create(tx); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func badDebtCategory(tx *gorm.DB) (*TransactionCategory, error) {
+	tc := &TransactionCategory{}
+	err := tx.Where(TransactionCategory{Name: "Bad Debt"}).FirstOrCreate(tc).Error
+	return tc, err
+}
+
+// WriteOffReport totals write-offs by batch and by the fiscal year they were
+// made in.
+func WriteOffReport() ([]WriteOffSummary, error) {
+	var writeOffs []WriteOff
+	if err := db.Driver.Order("created_at").Find(&writeOffs).Error; err != nil {
+		return nil, err
+	}
+	var batches []Batch
+	if err := db.Driver.Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	batchNames := map[uint]string{}
+	for _, batch := range batches {
+		batchNames[batch.ID] = batch.Name
+	}
+
+	summaries := []WriteOffSummary{}
+	index := map[string]int{}
+	for _, writeOff := range writeOffs {
+		start, end := FiscalYearBounds(writeOff.CreatedAt)
+		fiscalYear := fmt.Sprintf("%d-%02d", start.Year(), end.Year() % 100)
+		key := fmt.Sprintf("%d/%s", writeOff.BatchId, fiscalYear)
+		i, ok := index[key]
+		if !ok {
+			i = len(summaries)
+			index[key] = i
+			summaries = append(summaries, WriteOffSummary{BatchId: writeOff.BatchId, BatchName: batchNames[writeOff.BatchId], FiscalYear: fiscalYear})
+		}
+
+		summaries[i].WriteOffs = summaries[i].WriteOffs + 1
+		summaries[i].Amount = summaries[i].Amount + writeOff.Amount
+		if writeOff.Status == "Reversed" {
+			summaries[i].Reversed = summaries[i].Reversed + writeOff.Amount
+		}
+		summaries[i].Outstanding = summaries[i].Amount - summaries[i].Reversed
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].FiscalYear != summaries[j].FiscalYear {
+			return summaries[i].FiscalYear > summaries[j].FiscalYear
+		}
+		return summaries[i].BatchName < summaries[j].BatchName
+	})
+	return summaries, nil
+}
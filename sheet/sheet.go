@@ -0,0 +1,308 @@
+// Package sheet reads tabular uploads, CSV or XLSX, as rows of strings.
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSize is the largest upload read, 10 MB.
+const MaxSize = 10 << 20
+
+var ErrUnsupportedFormat = errors.New("Only CSV and XLSX files are supported")
+var ErrTooLarge = errors.New("File is larger than 10 MB")
+
+// Read returns the rows of the file, picking the format from its extension.
+// Only the first worksheet of an XLSX workbook is read.
+func Read(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ReadCSV(r)
+	case ".xlsx":
+		return ReadXLSX(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// readAll reads the whole upload, refusing one larger than MaxSize.
+func readAll(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize + 1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func ReadCSV(r io.Reader) ([][]string, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+type xlsxRels struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Id string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		Id   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtId int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Style  int      `xml:"s,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX reads the first worksheet. Cells formatted as dates hold a day
+// count and are read back as 2006-01-02.
+func ReadXLSX(r io.Reader) ([][]string, error) {
+	data, err := readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetName, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(file, &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	dateStyles := map[int]bool{}
+	if file, ok := files["xl/styles.xml"]; ok {
+		var styles xlsxStyles
+		if err := decodeXML(file, &styles); err != nil {
+			return nil, err
+		}
+		dateStyles = stylesWithDates(styles)
+	}
+
+	var worksheet xlsxSheet
+	file, ok := files[sheetName]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+	if err := decodeXML(file, &worksheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{}
+	for _, row := range worksheet.Rows {
+		values := []string{}
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				column = columnIndex(cell.Ref)
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				if index, err := strconv.Atoi(value); err == nil && index < len(sharedStrings.Items) {
+					value = sharedStrings.Items[index].String()
+				}
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "", "n":
+				if dateStyles[cell.Style] {
+					value = formatDate(value)
+				} else {
+					value = formatNumber(value)
+				}
+			}
+			values[column] = value
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+func firstSheet(files map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	var rels xlsxRels
+	workbookFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOk := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOk {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	if err := decodeXML(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	if err := decodeXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", ErrUnsupportedFormat
+	}
+	for _, rel := range rels.Relationships {
+		if rel.Id == workbook.Sheets[0].Id {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+func decodeXML(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(v)
+}
+
+// columnIndex turns the letters of a cell reference ("AB12") into a zero
+// based column index.
+func columnIndex(ref string) int {
+	index := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		index = index * 26 + int(ch - 'A' + 1)
+	}
+	return index - 1
+}
+
+// formatNumber prints whole numbers without exponent or decimals, so phone
+// and Aadhaar numbers typed into numeric cells read back as typed.
+func formatNumber(value string) string {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number != float64(int64(number)) {
+		return value
+	}
+	return strconv.FormatInt(int64(number), 10)
+}
+
+// stylesWithDates picks the cell styles whose number format shows a date:
+// the built-in date formats and custom ones with days or years in them.
+func stylesWithDates(styles xlsxStyles) map[int]bool {
+	dateFormats := map[int]bool{14: true, 15: true, 16: true, 17: true, 22: true}
+	for _, numFmt := range styles.NumFmts {
+		if isDateFormat(numFmt.Code) {
+			dateFormats[numFmt.Id] = true
+		}
+	}
+	dateStyles := map[int]bool{}
+	for i, xf := range styles.CellXfs {
+		if dateFormats[xf.NumFmtId] {
+			dateStyles[i] = true
+		}
+	}
+	return dateStyles
+}
+
+// isDateFormat looks for day or year codes outside quoted text and
+// [bracketed] sections of a format code.
+func isDateFormat(code string) bool {
+	quoted, bracketed := false, false
+	for _, ch := range strings.ToLower(code) {
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case quoted:
+		case ch == '[':
+			bracketed = true
+		case ch == ']':
+			bracketed = false
+		case bracketed:
+		case ch == 'd' || ch == 'y':
+			return true
+		}
+	}
+	return false
+}
+
+// formatDate turns a day count of the 1900 date system into 2006-01-02.
+// Day 60 is the 29 February 1900 the system counts, so days before it are
+// one off from the rest.
+func formatDate(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 1 {
+		return value
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if serial < 61 {
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	return epoch.AddDate(0, 0, int(serial)).Format("2006-01-02")
+}
@@ -0,0 +1,156 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writer streams rows out one at a time. The first row written is the header.
+type Writer interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// NewWriter returns the writer for format, one of csv, xlsx or jsonl.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "csv":
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case "xlsx":
+		return newXLSXWriter(w)
+	case "jsonl":
+		return &jsonLinesWriter{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func ContentType(format string) string {
+	switch format {
+	case "csv":
+		return "text/csv"
+	case "xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/x-ndjson"
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (cw *csvWriter) WriteRow(values []string) error {
+	return cw.writer.Write(values)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// jsonLinesWriter writes each row after the header as one JSON object keyed
+// by the header.
+type jsonLinesWriter struct {
+	encoder *json.Encoder
+	header  []string
+}
+
+func (jw *jsonLinesWriter) WriteRow(values []string) error {
+	if jw.header == nil {
+		jw.header = values
+		return nil
+	}
+	object := make(map[string]string, len(values))
+	for i, value := range values {
+		if i < len(jw.header) {
+			object[jw.header[i]] = value
+		}
+	}
+	return jw.encoder.Encode(object)
+}
+
+func (jw *jsonLinesWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter writes a single sheet workbook with inline strings, so rows go
+// straight to the zip stream without a shared string table.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+	}
+	for _, part := range parts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(writer, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	writer, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxWriter{archive: archive, sheet: bufio.NewWriter(writer)}
+	_, err = xw.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return xw, err
+}
+
+func (xw *xlsxWriter) WriteRow(values []string) error {
+	xw.rows = xw.rows + 1
+	fmt.Fprintf(xw.sheet, `<row r="%d">`, xw.rows)
+	for i, value := range values {
+		fmt.Fprintf(xw.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(i), xw.rows)
+		if err := xml.EscapeText(xw.sheet, []byte(value)); err != nil {
+			return err
+		}
+		xw.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if _, err := xw.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.archive.Close()
+}
+
+// columnName is the inverse of columnIndex, 0 gives "A" and 27 gives "AB".
+func columnName(index int) string {
+	var name strings.Builder
+	for index = index + 1; index > 0; index = (index - 1) / 26 {
+		name.WriteByte(byte('A' + (index - 1) % 26))
+	}
+	letters := []byte(name.String())
+	for i, j := 0, len(letters) - 1; i < j; i, j = i + 1, j - 1 {
+		letters[i], letters[j] = letters[j], letters[i]
+	}
+	return string(letters)
+}
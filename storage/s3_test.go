@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is an S3 stand-in that checks the Signature Version 4 of every
+// request the way AWS does, from the path as received, and keeps objects in
+// memory. Requests failing the check are refused and kept in rejected.
+type fakeS3 struct {
+	region    string
+	accessKey string
+	secretKey string
+	mu        sync.Mutex
+	objects   map[string][]byte
+	rejected  []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		f.mu.Lock()
+		f.rejected = append(f.rejected, fmt.Sprintf("%s %s: %v", r.Method, r.RequestURI, err))
+		f.mu.Unlock()
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	path, err := url.PathUnescape(strings.SplitN(r.RequestURI, "?", 2)[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[path] = body
+	case http.MethodGet:
+		body, ok := f.objects[path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) verify(r *http.Request) error {
+	authorization := r.Header.Get("Authorization")
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 "), ", ") {
+		if parts := strings.SplitN(field, "=", 2); len(parts) == 2 {
+			fields[parts[0]] = parts[1]
+		}
+	}
+	credential := strings.SplitN(fields["Credential"], "/", 2)
+	if len(credential) != 2 || credential[0] != f.accessKey {
+		return fmt.Errorf("bad credential %q", fields["Credential"])
+	}
+	scope := credential[1]
+	day := strings.SplitN(scope, "/", 2)[0]
+
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != r.Header.Get("X-Amz-Content-Sha256") {
+		return fmt.Errorf("payload hash mismatch")
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	sort.Strings(signedHeaders)
+	var canonicalHeaders strings.Builder
+	for _, header := range signedHeaders {
+		value := r.Header.Get(header)
+		if header == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(header + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	path, err := url.PathUnescape(strings.SplitN(r.RequestURI, "?", 2)[0])
+	if err != nil {
+		return err
+	}
+	var canonicalPath strings.Builder
+	for _, c := range []byte(path) {
+		if strings.IndexByte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~/", c) >= 0 {
+			canonicalPath.WriteByte(c)
+		} else {
+			fmt.Fprintf(&canonicalPath, "%%%02X", c)
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{r.Method, canonicalPath.String(), r.URL.RawQuery, canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"), r.Header.Get("X-Amz-Content-Sha256")}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", r.Header.Get("X-Amz-Date"), scope, hex.EncodeToString(hash[:])}, "\n")
+
+	key := []byte("AWS4" + f.secretKey)
+	for _, part := range []string{day, f.region, "s3", "aws4_request"} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	if signature := hex.EncodeToString(mac.Sum(nil)); signature != fields["Signature"] {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	fake := &fakeS3{region: "ap-south-1", accessKey: "AKID", secretKey: "secret", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s3 := &S3{Endpoint: server.URL, Region: fake.region, Bucket: "documents", AccessKey: fake.accessKey, SecretKey: fake.secretKey}
+
+	names := []string{"plain.pdf", "Report+Card.pdf", "fees&dues.pdf", "a,b;c=d.pdf", "user@home:$1.pdf",
+		"with space.pdf", "marks (final).pdf", "परीक्षा.pdf"}
+	for _, name := range names {
+		key := "students/1/marksheet/0123456789abcdef-" + name
+		body := []byte("contents of " + name)
+
+		if err := s3.Put(key, body, "application/pdf"); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+		reader, err := s3.Get(key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		got, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(got) != string(body) {
+			t.Errorf("Get(%q) = %q, want %q", key, got, body)
+		}
+		if err := s3.Delete(key); err != nil {
+			t.Fatalf("Delete(%q): %v", key, err)
+		}
+		if _, err := s3.Get(key); err != ErrNotFound {
+			t.Errorf("Get(%q) after Delete = %v, want ErrNotFound", key, err)
+		}
+	}
+	for _, rejected := range fake.rejected {
+		t.Error(rejected)
+	}
+}
+
+func TestS3SignatureIsChecked(t *testing.T) {
+	fake := &fakeS3{region: "ap-south-1", accessKey: "AKID", secretKey: "secret", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s3 := &S3{Endpoint: server.URL, Region: fake.region, Bucket: "documents", AccessKey: fake.accessKey, SecretKey: "wrong"}
+
+	if err := s3.Put("students/1/fees&dues.pdf", []byte("x"), "application/pdf"); err == nil {
+		t.Fatal("Put with a wrong secret key succeeded")
+	}
+	if len(fake.rejected) != 1 || !strings.Contains(fake.rejected[0], "signature mismatch") {
+		t.Errorf("rejected = %q, want one signature mismatch", fake.rejected)
+	}
+}
+
+func TestURIEncode(t *testing.T) {
+	cases := map[string]string{
+		"/documents/Report+Card.pdf": "/documents/Report%2BCard.pdf",
+		"/documents/fees&dues.pdf":   "/documents/fees%26dues.pdf",
+		"/documents/a,b;c=d@e:$.pdf": "/documents/a%2Cb%3Bc%3Dd%40e%3A%24.pdf",
+		"/documents/with space~.pdf": "/documents/with%20space~.pdf",
+	}
+	for path, want := range cases {
+		if got := uriEncode(path); got != want {
+			t.Errorf("uriEncode(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package storage keeps uploaded files, on the local filesystem or in an
+// S3-compatible bucket.
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrNotFound = errors.New("File not found")
+
+type Storage interface {
+	Put(key string, body []byte, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// FromEnv picks the backend from STORAGE_BACKEND, "local" (default) or "s3".
+// The local backend writes under STORAGE_PATH, "uploads" by default. The s3
+// backend reads S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY and
+// S3_SECRET_KEY.
+func FromEnv() Storage {
+	if os.Getenv("STORAGE_BACKEND") == "s3" {
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return &S3{Endpoint: os.Getenv("S3_ENDPOINT"), Region: region, Bucket: os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"), SecretKey: os.Getenv("S3_SECRET_KEY")}
+	}
+
+	root := os.Getenv("STORAGE_PATH")
+	if root == "" {
+		root = "uploads"
+	}
+	return &Local{Root: root}
+}
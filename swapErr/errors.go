@@ -10,3 +10,53 @@ var ErrForbidden = errors.New("forbidden")
 var ErrAlreadyChecked = errors.New("Already Checked")
 var ErrAlreadyHasClass = errors.New("Already Assigned to Class")
 var ErrEmptyRole = errors.New("Empty Role")
+var ErrRoomFull = errors.New("Room is full")
+var ErrRoomNotInHostel = errors.New("Room does not belong to hostel")
+var ErrAlreadyInRoom = errors.New("Already assigned to room")
+var ErrNoBillingCycle = errors.New("Hostel has no billing cycle; set one before transferring")
+var ErrFiscalYearClosed = errors.New("Fiscal year is closed")
+var ErrFiscalYearNotEnded = errors.New("Fiscal year has not ended")
+var ErrPreviousFiscalYearOpen = errors.New("Previous fiscal year is not closed")
+var ErrInvalidFiscalYear = errors.New("Fiscal year overlaps or has invalid dates")
+var ErrInsufficientBalance = errors.New("Insufficient student account balance")
+var ErrOutOfStock = errors.New("Item out of stock")
+var ErrNotPositive = errors.New("must be greater than zero")
+var ErrDepositFor = errors.New("Deposit must be for one hostel or class enrollment of the student")
+var ErrDepositSettled = errors.New("Deposit already settled")
+var ErrDeductionExceedsDeposit = errors.New("Deduction exceeds deposit held")
+var ErrFeeRevisionApplied = errors.New("Fee revision already applied")
+var ErrFeeRevisionNotDue = errors.New("Fee revision is not effective yet")
+var ErrFeeRevisionSuperseded = errors.New("A later fee revision is already applied")
+var ErrFeeRevisionRequired = errors.New("Fee is changed through a fee revision")
+var ErrWriteOffExceedsDues = errors.New("Write-off exceeds outstanding dues")
+var ErrWriteOffReversed = errors.New("Write-off already reversed")
+var ErrInvalidStatusTransition = errors.New("Student status transition is not allowed")
+var ErrBalanceNotCleared = errors.New("Student balance is not cleared")
+var ErrNoClassAssigned = errors.New("Student is not assigned to a class")
+var ErrDocumentTooLarge = errors.New("Document is larger than 5 MB")
+var ErrDocumentType = errors.New("Document type is not allowed for this category")
+var ErrInvalidAadhaar = errors.New("Invalid Aadhaar number")
+var ErrDuplicateStudent = errors.New("Possible duplicate student")
+var ErrMergeConflict = errors.New("Both students have an active class or hostel; remove one first")
+var ErrMergeSameStudent = errors.New("Cannot merge a student into itself")
+var ErrPromotionMapping = errors.New("Each class must be promoted to one other existing class")
+var ErrAlreadyInClass = errors.New("Student is already in this class")
+var ErrInvalidIdCard = errors.New("Invalid or tampered ID card")
+var ErrIdCardExpired = errors.New("ID card has expired")
+var ErrNotInTrash = errors.New("Record is not in the trash")
+var ErrTrashDependency = errors.New("Record depends on or is used by other records")
+var ErrTrashRetention = errors.New("Record is still within the trash retention period")
+var ErrStudentMerged = errors.New("Student was merged into another student")
+var ErrCertificateIssued = errors.New("Leaving certificate already issued")
+var ErrCertificateCancelled = errors.New("Certificate already cancelled")
+var ErrCertificateFields = errors.New("Missing certificate fields")
+var ErrStudentLeft = errors.New("Student has left the school")
+var ErrNoFeesPaid = errors.New("No fees paid")
+var ErrCustomFieldKeyTaken = errors.New("key already used")
+var ErrCustomFieldOptions = errors.New("select needs options")
+var ErrCustomFieldUnknown = errors.New("unknown field")
+var ErrTagExists = errors.New("tag already exists")
+var ErrTagName = errors.New("Tag names must be at most 50 characters")
+var ErrNoTags = errors.New("No tags given")
+var ErrNoStudents = errors.New("No students given")
+var ErrSegmentExists = errors.New("name already used")